@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/google-sheets-datasource/pkg/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -25,20 +26,15 @@ type GoogleSheetsDataSource struct {
 
 // NewDataSource creates the google sheets datasource and sets up all the routes
 func NewDataSource(mux *http.ServeMux) *GoogleSheetsDataSource {
-	queriesTotal := prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name:      "data_query_total",
-			Help:      "data query counter",
-			Namespace: metricNamespace,
-		},
-		[]string{"scenario"},
-	)
-	prometheus.MustRegister(queriesTotal)
+	metrics := googlesheets.NewMetrics(metricNamespace)
+	metrics.MustRegister(prometheus.DefaultRegisterer)
 
 	cache := cache.New(300*time.Second, 5*time.Second)
 	ds := &GoogleSheetsDataSource{
 		googlesheet: &googlesheets.GoogleSheets{
-			Cache: cache,
+			Cache:                   cache,
+			MaxConcurrentTransforms: googlesheets.DefaultMaxConcurrentTransforms,
+			Metrics:                 metrics,
 		},
 	}
 
@@ -64,6 +60,12 @@ func (ds *GoogleSheetsDataSource) CheckHealth(ctx context.Context, req *backend.
 		return res, nil
 	}
 
+	if err := googlesheets.ValidateTLSSettings(config); err != nil {
+		res.Status = backend.HealthStatusError
+		res.Message = err.Error()
+		return res, nil
+	}
+
 	client, err := googlesheets.NewGoogleClient(ctx, config)
 	if err != nil {
 		res.Status = backend.HealthStatusError
@@ -94,7 +96,9 @@ func (ds *GoogleSheetsDataSource) QueryData(ctx context.Context, req *backend.Qu
 	for _, q := range req.Queries {
 		queryModel, err := models.GetQueryModel(q)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read query: %w", err)
+			// Only fail this query, the others can still be answered
+			res.Responses[q.RefID] = backend.DataResponse{Error: fmt.Errorf("failed to read query: %w", err)}
+			continue
 		}
 
 		if len(queryModel.Spreadsheet) < 1 {
@@ -110,6 +114,41 @@ func (ds *GoogleSheetsDataSource) QueryData(ctx context.Context, req *backend.Qu
 	return res, nil
 }
 
+// SubscribeStream allows subscriptions to the streams of queries run with Stream enabled.
+func (ds *GoogleSheetsDataSource) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	uid := ""
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		uid = req.PluginContext.DataSourceInstanceSettings.UID
+	}
+	if !ds.googlesheet.HasStream(uid, req.Path) {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK, UseRunStream: true}, nil
+}
+
+// PublishStream rejects publications, streams only carry rows read from the sheet.
+func (ds *GoogleSheetsDataSource) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+// RunStream polls the range of a stream and sends the appended rows until the last subscriber leaves.
+func (ds *GoogleSheetsDataSource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender backend.StreamPacketSender) error {
+	config, err := models.LoadSettings(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(ds.googlesheet.StreamInterval(config.UID, req.Path))
+	defer ticker.Stop()
+	return ds.googlesheet.RunStream(ctx, req.Path, config, ticker.C, func(frame *data.Frame) error {
+		body, err := data.FrameToJSON(frame, true, true)
+		if err != nil {
+			return err
+		}
+		return sender.Send(&backend.StreamPacket{Data: body})
+	})
+}
+
 func writeResult(rw http.ResponseWriter, path string, val interface{}, err error) {
 	response := make(map[string]interface{})
 	code := http.StatusOK
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/googlesheets"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryData(t *testing.T) {
+	spreadsheet, err := ioutil.ReadFile("./googlesheets/testdata/mixed-data.json")
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(spreadsheet)
+	}))
+	defer server.Close()
+
+	ds := &GoogleSheetsDataSource{
+		googlesheet: &googlesheets.GoogleSheets{
+			Cache: cache.New(300*time.Second, 5*time.Second),
+		},
+	}
+
+	t.Run("failing queries don't break the other queries", func(t *testing.T) {
+		res, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData:                []byte(`{"authType": "key", "apiEndpoint": "` + server.URL + `/"}`),
+					DecryptedSecureJSONData: map[string]string{"apiKey": "secret"},
+				},
+			},
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: []byte(`{"spreadsheet": "someid"}`)},
+				{RefID: "B", JSON: []byte(`{"spreadsheet": `)},
+				{RefID: "C", JSON: []byte(`{"spreadsheet": "someid", "regexFilter": {"Number": "("}}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Responses, 3)
+
+		assert.NoError(t, res.Responses["A"].Error)
+		require.Len(t, res.Responses["A"].Frames, 1)
+		assert.Greater(t, res.Responses["A"].Frames[0].Rows(), 0)
+
+		assert.Error(t, res.Responses["B"].Error)
+		assert.Error(t, res.Responses["C"].Error)
+		assert.Empty(t, res.Responses["C"].Frames)
+	})
+}
+
+func TestQueryDataNotices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": {"code": 400, "message": "Range ('Sheet1'!A1:ZZ10) exceeds grid limits. Max rows: 1000, max columns: 26", "status": "INVALID_ARGUMENT"}}`))
+	}))
+	defer server.Close()
+
+	ds := &GoogleSheetsDataSource{
+		googlesheet: &googlesheets.GoogleSheets{
+			Cache: cache.New(300*time.Second, 5*time.Second),
+		},
+	}
+
+	t.Run("out of bounds range returns a frame with a warning notice", func(t *testing.T) {
+		res, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData:                []byte(`{"authType": "key", "apiEndpoint": "` + server.URL + `/"}`),
+					DecryptedSecureJSONData: map[string]string{"apiKey": "secret"},
+				},
+			},
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: []byte(`{"spreadsheet": "someid", "range": "Sheet1!A1:ZZ10"}`)},
+			},
+		})
+		require.NoError(t, err)
+
+		dr := res.Responses["A"]
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1)
+		require.NotNil(t, dr.Frames[0].Meta)
+		require.Len(t, dr.Frames[0].Meta.Notices, 1)
+		notice := dr.Frames[0].Meta.Notices[0]
+		assert.Equal(t, data.NoticeSeverityWarning, notice.Severity)
+		assert.Contains(t, notice.Text, "exceeds grid limits")
+	})
+}
+
+func TestSnapshotQuery(t *testing.T) {
+	spreadsheet, err := ioutil.ReadFile("./googlesheets/testdata/orders.json")
+	require.NoError(t, err)
+	batchUpdates := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":batchUpdate") {
+			body, _ := ioutil.ReadAll(r.Body)
+			batchUpdates = append(batchUpdates, string(body))
+			_, _ = w.Write([]byte(`{"spreadsheetId": "someid", "replies": [{}, {}]}`))
+			return
+		}
+		_, _ = w.Write(spreadsheet)
+	}))
+	defer server.Close()
+
+	ds := NewDataSource(http.NewServeMux())
+	pluginContext := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+			JSONData:                []byte(`{"authType": "key", "apiEndpoint": "` + server.URL + `/", "allowWrites": true}`),
+			DecryptedSecureJSONData: map[string]string{"apiKey": "secret"},
+		},
+	}
+
+	t.Run("a snapshot query writes the sheet and returns its id", func(t *testing.T) {
+		res, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginContext,
+			Queries: []backend.DataQuery{
+				{RefID: "A", QueryType: "snapshot", JSON: []byte(`{"spreadsheet": "someid", "range": "Sheet1"}`)},
+			},
+		})
+		require.NoError(t, err)
+		dr := res.Responses["A"]
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1)
+		assert.Equal(t, "sheetId", dr.Frames[0].Fields[0].Name)
+		assert.NotZero(t, dr.Frames[0].Fields[0].At(0))
+		assert.Equal(t, int64(3), dr.Frames[0].Fields[2].At(0))
+		require.Len(t, batchUpdates, 1)
+		assert.Contains(t, batchUpdates[0], `"addSheet"`)
+		assert.Contains(t, batchUpdates[0], `"appendCells"`)
+	})
+}
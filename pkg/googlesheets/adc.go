@@ -0,0 +1,79 @@
+package googlesheets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// findDefaultCredentials looks up the Application Default Credentials, replaced in tests.
+var findDefaultCredentials = google.FindDefaultCredentials
+
+// newDefaultCredentialsServices creates the sheets and drive services from the Application Default Credentials,
+// e.g. the Workload Identity of a GKE pod, and describes where the credentials were found.
+func newDefaultCredentialsServices(ctx context.Context, auth *models.DatasourceSettings) (*sheets.Service, *drive.Service, string, error) {
+	// Only need readonly access to spreadsheets unless writes are allowed
+	sheetsScope := sheets.SpreadsheetsReadonlyScope
+	if auth.AllowWrites {
+		sheetsScope = sheets.SpreadsheetsScope
+	}
+	credentials, err := findDefaultCredentials(ctx, sheetsScope, drive.DriveMetadataReadonlyScope)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("unable to find default credentials: %w", err)
+	}
+
+	transport, err := newTransport(auth)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	client := oauth2.NewClient(proxyContext(ctx, transport), credentials.TokenSource)
+
+	opts := []option.ClientOption{option.WithHTTPClient(client)}
+	if len(auth.APIEndpoint) > 0 {
+		opts = append(opts, option.WithEndpoint(auth.APIEndpoint))
+	}
+	sheetsService, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return sheetsService, driveService, credentialSource(credentials), nil
+}
+
+// credentialSource describes the kind and origin of default credentials, following the lookup order of FindDefaultCredentials.
+func credentialSource(credentials *google.Credentials) string {
+	if len(credentials.JSON) == 0 {
+		return "metadata server"
+	}
+
+	file := struct {
+		Type string `json:"type"`
+	}{}
+	kind := "credentials"
+	if err := json.Unmarshal(credentials.JSON, &file); err == nil {
+		switch file.Type {
+		case "service_account":
+			kind = "service account key"
+		case "authorized_user":
+			kind = "user credentials"
+		case "external_account":
+			kind = "workload identity federation"
+		}
+	}
+
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return fmt.Sprintf("%s from GOOGLE_APPLICATION_CREDENTIALS (%s)", kind, path)
+	}
+	return kind + " from the gcloud configuration"
+}
@@ -0,0 +1,42 @@
+package googlesheets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// validateAlertFrame checks that a frame is a single series alerting can evaluate: one time field and one numeric field.
+func validateAlertFrame(frame *data.Frame) error {
+	var timeFields, numberFields, otherFields []string
+	for _, field := range frame.Fields {
+		name := fmt.Sprintf("%q", field.Name)
+		switch {
+		case field.Type().Time():
+			timeFields = append(timeFields, name)
+		case field.Type().Numeric():
+			numberFields = append(numberFields, name)
+		default:
+			otherFields = append(otherFields, name)
+		}
+	}
+
+	if len(timeFields) != 1 {
+		return fmt.Errorf("alert mode needs exactly one time field, found %d%s", len(timeFields), listFields(timeFields))
+	}
+	if len(numberFields) != 1 {
+		return fmt.Errorf("alert mode needs exactly one numeric field, found %d%s", len(numberFields), listFields(numberFields))
+	}
+	if len(otherFields) > 0 {
+		return fmt.Errorf("alert mode only allows a time and a numeric field, remove the other fields: %s", strings.Join(otherFields, ", "))
+	}
+	return nil
+}
+
+func listFields(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(names, ", ")
+}
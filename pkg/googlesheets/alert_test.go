@@ -0,0 +1,52 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertMode(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/join.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("time column makes a valid alert frame", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeColumn: "Date", ColumnExcludeGlob: "Region", AlertMode: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.True(t, frame.Fields[0].Type().Time())
+		assert.NoError(t, validateAlertFrame(frame))
+	})
+
+	t.Run("frames without a time field are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ColumnExcludeGlob: "Region", AlertMode: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.EqualError(t, validateAlertFrame(frame), "alert mode needs exactly one time field, found 0")
+	})
+
+	t.Run("frames with extra fields are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeColumn: "Date", AlertMode: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.EqualError(t, validateAlertFrame(frame), `alert mode only allows a time and a numeric field, remove the other fields: "Region"`)
+	})
+
+	t.Run("missing time columns and epoch millis are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeColumn: "Timestamp"}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.Error(t, err)
+
+		assert.Error(t, validateQuery(&models.QueryModel{AlertMode: true, TimeAsEpochMillis: true}))
+	})
+}
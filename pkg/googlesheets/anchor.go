@@ -0,0 +1,59 @@
+package googlesheets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"google.golang.org/api/sheets/v4"
+)
+
+// findAnchorCell returns the row and column index of the first cell whose value equals value.
+func findAnchorCell(rows []*sheets.RowData, value string) (int, int, bool) {
+	for rowIndex, row := range rows {
+		for columnIndex, cell := range row.Values {
+			if cell != nil && strings.TrimSpace(cell.FormattedValue) == value {
+				return rowIndex, columnIndex, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// sliceFromAnchor returns the part of the grid data that starts at the configured offset from the anchor cell.
+func sliceFromAnchor(sheet *sheets.GridData, qm *models.QueryModel) (*sheets.GridData, error) {
+	anchorRow, anchorColumn, found := findAnchorCell(sheet.RowData, qm.AnchorCellValue)
+	if !found {
+		return nil, fmt.Errorf("anchor cell %q not found in range %q", qm.AnchorCellValue, qm.Range)
+	}
+
+	startRow := anchorRow + qm.AnchorRowOffset
+	startColumn := anchorColumn + qm.AnchorColumnOffset
+	if startRow < 0 || startColumn < 0 {
+		return nil, fmt.Errorf("anchor offset points outside of range %q", qm.Range)
+	}
+	if startRow >= len(sheet.RowData) {
+		return nil, fmt.Errorf("no data found at offset from anchor cell %q", qm.AnchorCellValue)
+	}
+
+	rows := make([]*sheets.RowData, 0, len(sheet.RowData)-startRow)
+	for _, row := range sheet.RowData[startRow:] {
+		values := []*sheets.CellData{}
+		if startColumn < len(row.Values) {
+			values = row.Values[startColumn:]
+		}
+		rows = append(rows, &sheets.RowData{Values: values})
+	}
+
+	rowMetadata := []*sheets.DimensionProperties{}
+	if startRow < len(sheet.RowMetadata) {
+		rowMetadata = sheet.RowMetadata[startRow:]
+	}
+
+	return &sheets.GridData{
+		RowData:     rows,
+		RowMetadata: rowMetadata,
+		StartRow:    sheet.StartRow + int64(startRow),
+		StartColumn: sheet.StartColumn + int64(startColumn),
+	}, nil
+}
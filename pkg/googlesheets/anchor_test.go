@@ -0,0 +1,63 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnchor(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/anchored.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("table below the anchor is read", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", AnchorCellValue: "START", AnchorRowOffset: 1}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(frame.Fields))
+		assert.Equal(t, "Month", frame.Fields[0].Name)
+		assert.Equal(t, "Sales", frame.Fields[1].Name)
+		require.Equal(t, 3, frame.Fields[1].Len())
+
+		month, ok := frame.Fields[0].At(0).(*string)
+		require.True(t, ok)
+		assert.Equal(t, "Jan", *month)
+
+		sales, ok := frame.Fields[1].At(2).(*float64)
+		require.True(t, ok)
+		assert.Equal(t, 15.0, *sales)
+	})
+
+	t.Run("column offset is applied", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", AnchorCellValue: "START", AnchorRowOffset: 1, AnchorColumnOffset: 1}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(frame.Fields))
+		assert.Equal(t, "Sales", frame.Fields[0].Name)
+	})
+
+	t.Run("missing anchor returns an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", AnchorCellValue: "BEGIN"}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `anchor cell "BEGIN" not found`)
+	})
+
+	t.Run("offset past the end of the range returns an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", AnchorCellValue: "START", AnchorRowOffset: 10}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.Error(t, err)
+	})
+}
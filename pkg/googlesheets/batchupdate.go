@@ -0,0 +1,63 @@
+package googlesheets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// parseBatchUpdateRequest decodes the raw batchUpdate request of a query and rejects empty requests.
+func parseBatchUpdateRequest(qm *models.QueryModel) (*sheets.BatchUpdateSpreadsheetRequest, error) {
+	if len(qm.BatchUpdateRequest) == 0 {
+		return nil, fmt.Errorf("batch update request is empty")
+	}
+
+	request := &sheets.BatchUpdateSpreadsheetRequest{}
+	if err := json.Unmarshal(qm.BatchUpdateRequest, request); err != nil {
+		return nil, fmt.Errorf("invalid batch update request: %w", err)
+	}
+	if len(request.Requests) == 0 {
+		return nil, fmt.Errorf("batch update request has no requests")
+	}
+	return request, nil
+}
+
+// batchUpdate forwards a raw batchUpdate request to the Sheets API and returns a frame with one row per reply.
+func batchUpdate(ctx context.Context, client client, refID string, qm *models.QueryModel, config *models.DatasourceSettings) (*data.Frame, error) {
+	if !config.AllowWrites || !config.AllowBatchUpdate {
+		return nil, fmt.Errorf("batch updates are not enabled for this datasource")
+	}
+
+	request, err := parseBatchUpdateRequest(qm)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.BatchUpdate(ctx, qm.Spreadsheet, request)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int64, len(response.Replies))
+	replies := make([]string, len(response.Replies))
+	for i, reply := range response.Replies {
+		encoded, err := json.Marshal(reply)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i] = int64(i)
+		replies[i] = string(encoded)
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("request", nil, indexes),
+		data.NewField("reply", nil, replies),
+	)
+	frame.RefID = refID
+	frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{"spreadsheetId": response.SpreadsheetId}}
+	return frame, nil
+}
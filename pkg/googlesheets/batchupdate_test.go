@@ -0,0 +1,65 @@
+package googlesheets
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchUpdate(t *testing.T) {
+	repeatCell := json.RawMessage(`{
+		"requests": [{
+			"repeatCell": {
+				"range": {"sheetId": 0, "startRowIndex": 1, "endRowIndex": 2},
+				"cell": {"userEnteredFormat": {"backgroundColor": {"red": 1}}},
+				"fields": "userEnteredFormat.backgroundColor"
+			}
+		}]
+	}`)
+	config := &models.DatasourceSettings{AllowWrites: true, AllowBatchUpdate: true}
+
+	t.Run("request is forwarded to the API", func(t *testing.T) {
+		client := &fakeClient{}
+		qm := models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeBatchUpdate, BatchUpdateRequest: repeatCell}
+		require.NoError(t, validateQuery(&qm))
+
+		frame, err := batchUpdate(context.Background(), client, "A", &qm, config)
+		require.NoError(t, err)
+
+		require.Len(t, client.batchUpdateRequests, 1)
+		request := client.batchUpdateRequests[0].Requests[0].RepeatCell
+		require.NotNil(t, request)
+		assert.Equal(t, "userEnteredFormat.backgroundColor", request.Fields)
+		assert.Equal(t, int64(1), request.Range.StartRowIndex)
+		assert.Equal(t, 1.0, request.Cell.UserEnteredFormat.BackgroundColor.Red)
+
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, 1, frame.Rows())
+		assert.Equal(t, "someid", frame.Meta.Custom.(map[string]interface{})["spreadsheetId"])
+	})
+
+	t.Run("both flags are required", func(t *testing.T) {
+		for _, config := range []*models.DatasourceSettings{
+			{},
+			{AllowWrites: true},
+			{AllowBatchUpdate: true},
+		} {
+			client := &fakeClient{}
+			qm := models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeBatchUpdate, BatchUpdateRequest: repeatCell}
+			_, err := batchUpdate(context.Background(), client, "A", &qm, config)
+			assert.Error(t, err)
+			assert.Empty(t, client.batchUpdateRequests)
+		}
+	})
+
+	t.Run("empty payloads are rejected", func(t *testing.T) {
+		for _, payload := range []string{"", `{}`, `{"requests": []}`} {
+			qm := models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeBatchUpdate, BatchUpdateRequest: json.RawMessage(payload)}
+			assert.Error(t, validateQuery(&qm), payload)
+		}
+	})
+}
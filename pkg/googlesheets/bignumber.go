@@ -0,0 +1,64 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxSafeInteger is the first integer a float64 can not tell apart from its successor.
+const maxSafeInteger = 1 << 53
+
+// applyBigNumberColumns marks the listed columns as string columns so their number values keep every digit.
+func applyBigNumberColumns(columns []*ColumnDefinition, headers []string) (map[*ColumnDefinition]bool, error) {
+	bigNumberColumns := map[*ColumnDefinition]bool{}
+	for _, header := range headers {
+		column := findColumn(columns, header)
+		if column == nil {
+			return nil, fmt.Errorf("big number column %q not found", header)
+		}
+		column.ForceType(ColumTypeString)
+		bigNumberColumns[column] = true
+	}
+	return bigNumberColumns, nil
+}
+
+// bigNumberConverter formats number values with all their digits instead of the display format,
+// which may round or use scientific notation. Other values keep their formatted value.
+var bigNumberConverter = data.FieldConverter{
+	OutputFieldType: data.FieldTypeNullableString,
+	Converter: func(i interface{}) (interface{}, error) {
+		cellData, ok := i.(*sheets.CellData)
+		if !ok {
+			return nil, fmt.Errorf("expected type *sheets.CellData, but got %T", i)
+		}
+		s := cellData.FormattedValue
+		if cellData.EffectiveValue != nil && cellData.EffectiveValue.NumberValue != nil {
+			s = strconv.FormatFloat(*cellData.EffectiveValue.NumberValue, 'f', -1, 64)
+		}
+		return &s, nil
+	},
+}
+
+// unsafeIntegerWarnings warns about number columns holding integers too large to be exact as float64.
+func unsafeIntegerWarnings(frame *data.Frame, columns []*ColumnDefinition) []string {
+	warnings := []string{}
+	for i, column := range columns {
+		if column.GetType() != ColumTypeNumber {
+			continue
+		}
+
+		field := frame.Fields[i]
+		for row := 0; row < field.Len(); row++ {
+			value, ok := field.At(row).(*float64)
+			if ok && value != nil && math.Abs(*value) >= maxSafeInteger && *value == math.Trunc(*value) {
+				warnings = append(warnings, fmt.Sprintf("Column %q has integers beyond 2^53 that may have lost precision. Use big number columns to read them as strings", column.Header))
+				break
+			}
+		}
+	}
+	return warnings
+}
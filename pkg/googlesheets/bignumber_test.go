@@ -0,0 +1,45 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBigNumberAsString(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/big-numbers.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("listed columns keep every digit", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", BigNumberAsString: []string{"ID"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "1234567890123456", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "4000000000000001", *frame.Fields[0].At(2).(*string))
+		assert.Equal(t, 10.0, *frame.Fields[1].At(0).(*float64))
+		assert.Empty(t, meta["warnings"])
+	})
+
+	t.Run("integers beyond float64 precision are warned about", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{`Column "ID" has integers beyond 2^53 that may have lost precision. Use big number columns to read them as strings`}, meta["warnings"])
+	})
+
+	t.Run("missing columns are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", BigNumberAsString: []string{"Serial"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// capability is a single check run by the testAPI query type.
+type capability struct {
+	name  string
+	check func() error
+}
+
+// testCapabilities runs every capability check and returns a frame with one row per capability.
+// With default credentials, a first row reports which credentials were found. Writes are only checked when
+// they are allowed.
+func testCapabilities(ctx context.Context, client client, credentialSource string, refID string, qm *models.QueryModel, allowWrites bool) *data.Frame {
+	capabilities := []capability{
+		{
+			name: "Read grid data",
+			check: func() error {
+				_, err := client.GetSpreadsheet(ctx, qm.Spreadsheet, qm.Range, true, gridDataFields(qm))
+				return err
+			},
+		},
+		{
+			name: "List spreadsheets",
+			check: func() error {
+				_, err := client.GetSpreadsheetFiles(ctx)
+				return err
+			},
+		},
+	}
+	if allowWrites {
+		capabilities = append(capabilities, capability{
+			name: "Write spreadsheet",
+			check: func() error {
+				return checkWriteAccess(ctx, client, qm.Spreadsheet)
+			},
+		})
+	}
+
+	names := make([]string, len(capabilities))
+	passed := make([]bool, len(capabilities))
+	messages := make([]string, len(capabilities))
+	for i, c := range capabilities {
+		names[i] = c.name
+		if err := c.check(); err != nil {
+			messages[i] = err.Error()
+		} else {
+			passed[i] = true
+		}
+	}
+	if credentialSource != "" {
+		names = append([]string{"Default credentials"}, names...)
+		passed = append([]bool{true}, passed...)
+		messages = append([]string{credentialSource}, messages...)
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("capability", nil, names),
+		data.NewField("passed", nil, passed),
+		data.NewField("message", nil, messages),
+	)
+	frame.RefID = refID
+	return frame
+}
+
+// checkWriteAccess adds a temporary sheet and deletes it again in one batchUpdate, which needs write access
+// but leaves the spreadsheet unchanged.
+func checkWriteAccess(ctx context.Context, client client, spreadsheetID string) error {
+	sheetID, err := newSheetID()
+	if err != nil {
+		return err
+	}
+	_, err = client.BatchUpdate(ctx, spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{
+				SheetId: sheetID,
+				Title:   fmt.Sprintf("Grafana write check %d", sheetID),
+			}}},
+			{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: sheetID}},
+		},
+	})
+	return err
+}
@@ -0,0 +1,75 @@
+package googlesheets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	qm := &models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeTestAPI}
+
+	t.Run("all capabilities pass", func(t *testing.T) {
+		frame := testCapabilities(context.Background(), &fakeClient{}, "", "ref1", qm, false)
+		require.Equal(t, 3, len(frame.Fields))
+		require.Equal(t, 2, frame.Fields[0].Len())
+		for i := 0; i < frame.Fields[0].Len(); i++ {
+			assert.True(t, frame.Fields[1].At(i).(bool))
+			assert.Equal(t, "", frame.Fields[2].At(i))
+		}
+	})
+
+	t.Run("missing drive scope fails only the listing capability", func(t *testing.T) {
+		client := &fakeClient{filesErr: errors.New("insufficient authentication scopes")}
+		frame := testCapabilities(context.Background(), client, "", "ref1", qm, false)
+		assert.Equal(t, "Read grid data", frame.Fields[0].At(0))
+		assert.True(t, frame.Fields[1].At(0).(bool))
+		assert.Equal(t, "List spreadsheets", frame.Fields[0].At(1))
+		assert.False(t, frame.Fields[1].At(1).(bool))
+		assert.Equal(t, "insufficient authentication scopes", frame.Fields[2].At(1))
+	})
+
+	t.Run("missing sheets access fails only the read capability", func(t *testing.T) {
+		client := &fakeClient{getSpreadsheetErr: errors.New("the caller does not have permission")}
+		frame := testCapabilities(context.Background(), client, "", "ref1", qm, false)
+		assert.False(t, frame.Fields[1].At(0).(bool))
+		assert.True(t, frame.Fields[1].At(1).(bool))
+	})
+
+	t.Run("writes are checked with a temporary sheet when they are allowed", func(t *testing.T) {
+		client := &fakeClient{}
+		frame := testCapabilities(context.Background(), client, "", "ref1", qm, true)
+		require.Equal(t, 3, frame.Fields[0].Len())
+		assert.Equal(t, "Write spreadsheet", frame.Fields[0].At(2))
+		assert.True(t, frame.Fields[1].At(2).(bool))
+
+		require.Len(t, client.batchUpdateRequests, 1)
+		requests := client.batchUpdateRequests[0].Requests
+		require.Len(t, requests, 2)
+		require.NotNil(t, requests[0].AddSheet)
+		require.NotNil(t, requests[1].DeleteSheet)
+		assert.Equal(t, requests[0].AddSheet.Properties.SheetId, requests[1].DeleteSheet.SheetId)
+	})
+
+	t.Run("read-only credentials fail only the write capability", func(t *testing.T) {
+		client := &fakeClient{batchUpdateErr: errors.New("the caller does not have permission")}
+		frame := testCapabilities(context.Background(), client, "", "ref1", qm, true)
+		assert.True(t, frame.Fields[1].At(0).(bool))
+		assert.True(t, frame.Fields[1].At(1).(bool))
+		assert.False(t, frame.Fields[1].At(2).(bool))
+		assert.Equal(t, "the caller does not have permission", frame.Fields[2].At(2))
+	})
+
+	t.Run("the default credential source is reported first", func(t *testing.T) {
+		frame := testCapabilities(context.Background(), &fakeClient{}, "metadata server", "ref1", qm, false)
+		require.Equal(t, 3, frame.Fields[0].Len())
+		assert.Equal(t, "Default credentials", frame.Fields[0].At(0))
+		assert.True(t, frame.Fields[1].At(0).(bool))
+		assert.Equal(t, "metadata server", frame.Fields[2].At(0))
+		assert.Equal(t, "Read grid data", frame.Fields[0].At(1))
+	})
+}
@@ -0,0 +1,192 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// mapCells returns copies of rows with fn applied to every cell. The grid data may be
+// cached, so cells must never be modified in place. rowNumbers is updated for the copies.
+func mapCells(rows []*sheets.RowData, rowNumbers map[*sheets.RowData]int, fn func(*sheets.CellData) *sheets.CellData) []*sheets.RowData {
+	mapped := make([]*sheets.RowData, len(rows))
+	for i, row := range rows {
+		values := make([]*sheets.CellData, len(row.Values))
+		for j, cell := range row.Values {
+			values[j] = fn(cell)
+		}
+		mapped[i] = &sheets.RowData{Values: values}
+		rowNumbers[mapped[i]] = rowNumbers[row]
+	}
+	return mapped
+}
+
+// parseFiniteNumber parses a number written in a string cell. Words like "NaN" or "Inf", which ParseFloat
+// accepts, are not numbers.
+func parseFiniteNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+// trimCell trims whitespace from string cells. Strings that are numbers once trimmed become number cells.
+func trimCell(cell *sheets.CellData) *sheets.CellData {
+	if cell == nil || cell.EffectiveValue == nil || cell.EffectiveValue.StringValue == nil {
+		return cell
+	}
+
+	trimmed := *cell
+	value := strings.TrimSpace(*cell.EffectiveValue.StringValue)
+	trimmed.FormattedValue = strings.TrimSpace(cell.FormattedValue)
+	trimmed.EffectiveValue = &sheets.ExtendedValue{StringValue: &value}
+	if number, ok := parseFiniteNumber(value); ok {
+		trimmed.EffectiveValue = &sheets.ExtendedValue{NumberValue: &number}
+	}
+	return &trimmed
+}
+
+// nullTokenCell returns a cell mapper that empties cells whose trimmed value is one of the tokens, ignoring case.
+func nullTokenCell(tokens []string) func(*sheets.CellData) *sheets.CellData {
+	normalized := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		normalized[strings.ToLower(strings.TrimSpace(token))] = true
+	}
+
+	return func(cell *sheets.CellData) *sheets.CellData {
+		if cell == nil || !normalized[strings.ToLower(strings.TrimSpace(cell.FormattedValue))] {
+			return cell
+		}
+		return &sheets.CellData{}
+	}
+}
+
+// numericStripCell returns a cell mapper that removes the substrings from string cells and turns them into number
+// cells if the rest is a number, e.g. 1234 for "USD 1234" without "USD". Other cells are left alone.
+func numericStripCell(substrings []string) func(*sheets.CellData) *sheets.CellData {
+	return func(cell *sheets.CellData) *sheets.CellData {
+		if cell == nil || cell.EffectiveValue == nil || cell.EffectiveValue.StringValue == nil {
+			return cell
+		}
+
+		value := *cell.EffectiveValue.StringValue
+		for _, substring := range substrings {
+			value = strings.ReplaceAll(value, substring, "")
+		}
+		value = strings.TrimSpace(value)
+		number, ok := parseFiniteNumber(value)
+		if !ok {
+			return cell
+		}
+
+		stripped := *cell
+		stripped.FormattedValue = value
+		stripped.EffectiveValue = &sheets.ExtendedValue{NumberValue: &number}
+		return &stripped
+	}
+}
+
+// stripNumericColumns applies numericStripCell with the substrings of each column in strip to the cells of that column.
+func stripNumericColumns(rows []*sheets.RowData, columns []*ColumnDefinition, rowNumbers map[*sheets.RowData]int, strip map[string][]string) ([]*sheets.RowData, error) {
+	mappers := map[int]func(*sheets.CellData) *sheets.CellData{}
+	for name, substrings := range strip {
+		column := findColumn(columns, name)
+		if column == nil {
+			return nil, fmt.Errorf("numeric strip column %q not found", name)
+		}
+		mappers[column.ColumnIndex] = numericStripCell(substrings)
+	}
+
+	mapped := make([]*sheets.RowData, len(rows))
+	for i, row := range rows {
+		values := make([]*sheets.CellData, len(row.Values))
+		for j, cell := range row.Values {
+			if mapper, ok := mappers[j]; ok {
+				cell = mapper(cell)
+			}
+			values[j] = cell
+		}
+		mapped[i] = &sheets.RowData{Values: values}
+		rowNumbers[mapped[i]] = rowNumbers[row]
+	}
+	return mapped, nil
+}
+
+// newlineReplacers maps the NewlineHandling modes to how line breaks in strings are replaced. "keep" leaves them alone.
+var newlineReplacers = map[string]*strings.Replacer{
+	"space": strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " "),
+	"strip": strings.NewReplacer("\r\n", "", "\n", "", "\r", ""),
+}
+
+func validateNewlineHandling(mode string) error {
+	if _, ok := newlineReplacers[mode]; !ok && mode != "" && mode != "keep" {
+		return fmt.Errorf("invalid newline handling %q, expected keep, space or strip", mode)
+	}
+	return nil
+}
+
+// newlineCell returns a cell mapper that replaces the line breaks in string cells.
+func newlineCell(replacer *strings.Replacer) func(*sheets.CellData) *sheets.CellData {
+	return func(cell *sheets.CellData) *sheets.CellData {
+		if cell == nil || cell.EffectiveValue == nil || cell.EffectiveValue.StringValue == nil {
+			return cell
+		}
+
+		replaced := *cell
+		value := replacer.Replace(*cell.EffectiveValue.StringValue)
+		replaced.FormattedValue = replacer.Replace(cell.FormattedValue)
+		replaced.EffectiveValue = &sheets.ExtendedValue{StringValue: &value}
+		return &replaced
+	}
+}
+
+// Error cell policies, for cells with a formula error like #DIV/0! or #N/A.
+const (
+	errorCellNull       = "null"
+	errorCellKeepString = "keepString"
+	errorCellError      = "error"
+)
+
+func validateErrorCellPolicy(policy string) error {
+	switch policy {
+	case "", errorCellNull, errorCellKeepString, errorCellError:
+		return nil
+	}
+	return fmt.Errorf("invalid error cell policy %q, expected null, keepString or error", policy)
+}
+
+func isErrorCell(cell *sheets.CellData) bool {
+	return cell != nil && cell.EffectiveValue != nil && cell.EffectiveValue.ErrorValue != nil
+}
+
+// applyErrorCellPolicy handles the formula error cells of the columns. By default they are emptied, so that
+// they become null instead of turning number columns into strings. keepString leaves their error text and
+// error fails on the first one.
+func applyErrorCellPolicy(rows []*sheets.RowData, columns []*ColumnDefinition, rowNumbers map[*sheets.RowData]int, policy string) ([]*sheets.RowData, error) {
+	switch policy {
+	case errorCellKeepString:
+		return rows, nil
+	case errorCellError:
+		for _, row := range rows {
+			for _, column := range columns {
+				if column.ColumnIndex < len(row.Values) && isErrorCell(row.Values[column.ColumnIndex]) {
+					cell := row.Values[column.ColumnIndex]
+					return nil, fmt.Errorf("cell %s%d in column %q has the error %s: %s", getExcelColumnName(column.ColumnIndex+1),
+						rowNumbers[row], column.Header, cell.FormattedValue, cell.EffectiveValue.ErrorValue.Message)
+				}
+			}
+		}
+		return rows, nil
+	}
+
+	return mapCells(rows, rowNumbers, func(cell *sheets.CellData) *sheets.CellData {
+		if isErrorCell(cell) {
+			return &sheets.CellData{}
+		}
+		return cell
+	}), nil
+}
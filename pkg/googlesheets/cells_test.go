@@ -0,0 +1,224 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestTrimStrings(t *testing.T) {
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("padded values are trimmed and detected as numbers", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/padded.json")
+		require.NoError(t, err)
+
+		qm := models.QueryModel{Spreadsheet: "someid", TrimStrings: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type())
+		assert.Equal(t, "alpha", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "beta", *frame.Fields[0].At(1).(*string))
+		assert.Equal(t, "gamma", *frame.Fields[0].At(2).(*string))
+
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		assert.Equal(t, 42.0, *frame.Fields[1].At(0).(*float64))
+		assert.Equal(t, 8.0, *frame.Fields[1].At(2).(*float64))
+
+		t.Run("the source grid data is not modified", func(t *testing.T) {
+			assert.Equal(t, "  alpha ", sheet.Sheets[0].Data[0].RowData[1].Values[0].FormattedValue)
+		})
+	})
+
+	t.Run("words ParseFloat reads as numbers are kept as strings", func(t *testing.T) {
+		for _, word := range []string{" NaN", "nan ", " Inf ", "+inf", "Infinity"} {
+			value := word
+			trimmed := trimCell(&sheets.CellData{FormattedValue: value, EffectiveValue: &sheets.ExtendedValue{StringValue: &value}})
+			require.NotNil(t, trimmed.EffectiveValue.StringValue, word)
+			assert.Nil(t, trimmed.EffectiveValue.NumberValue, word)
+		}
+	})
+
+	t.Run("padded values are kept by default", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/padded.json")
+		require.NoError(t, err)
+
+		qm := models.QueryModel{Spreadsheet: "someid"}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "  alpha ", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+	})
+}
+
+func TestNullTokens(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/null-tokens.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("tokens make the column a string column by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+	})
+
+	t.Run("null tokens let the column parse as numeric", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid", NullTokens: []string{"n/a", " - "}})
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+		assert.Equal(t, 1.0, *field.At(0).(*float64))
+		assert.Nil(t, field.At(1))
+		assert.Nil(t, field.At(2))
+		assert.Equal(t, 3.0, *field.At(3).(*float64))
+		assert.Nil(t, field.At(4))
+		assert.Empty(t, meta["warnings"])
+		assert.Equal(t, "N/A", sheet.Sheets[0].Data[0].RowData[2].Values[1].FormattedValue)
+	})
+}
+
+func TestEmptyStrings(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/empty-strings.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	meta := make(map[string]interface{})
+	frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+	require.NoError(t, err)
+
+	field := frame.Fields[0]
+	require.Equal(t, data.FieldTypeNullableString, field.Type())
+	assert.Equal(t, "a", *field.At(0).(*string))
+	assert.Nil(t, field.At(1), "empty cell is null")
+	require.NotNil(t, field.At(2), "empty formula result is an empty string")
+	assert.Equal(t, "", *field.At(2).(*string))
+	assert.Equal(t, "b", *field.At(3).(*string))
+}
+
+func TestNewlineHandling(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/multiline.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	tests := map[string][]string{
+		"":      {"first line\nsecond line", "windows\r\nline"},
+		"keep":  {"first line\nsecond line", "windows\r\nline"},
+		"space": {"first line second line", "windows line"},
+		"strip": {"first linesecond line", "windowsline"},
+	}
+	for mode, expected := range tests {
+		t.Run("newlines with mode "+mode, func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", NewlineHandling: mode}
+			frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected[0], *frame.Fields[0].At(0).(*string))
+			assert.Equal(t, expected[1], *frame.Fields[0].At(1).(*string))
+			assert.Equal(t, 2.0, *frame.Fields[1].At(1).(*float64))
+		})
+	}
+
+	t.Run("unknown modes are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{NewlineHandling: "wrap"}))
+	})
+}
+
+func TestMaxStringLength(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/long-strings.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("long strings are truncated with an ellipsis", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", MaxStringLength: 6}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "short", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "a rath…", *frame.Fields[0].At(1).(*string))
+		assert.Equal(t, "ünïcød…", *frame.Fields[0].At(2).(*string))
+		assert.Equal(t, 2, meta["truncatedCells"])
+	})
+
+	t.Run("strings are not truncated by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "a rather long note about the incident", *frame.Fields[0].At(1).(*string))
+		assert.NotContains(t, meta, "truncatedCells")
+	})
+}
+
+func TestNumericStrip(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/prefixed-numbers.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("stripped columns are detected as numbers", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", NumericStrip: map[string][]string{
+			"Price": {"USD", ","},
+			"Stock": {"units"},
+		}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		price := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, price.Type())
+		assert.Equal(t, 1234.0, *price.At(0).(*float64))
+		assert.Equal(t, 89.5, *price.At(1).(*float64))
+		assert.Equal(t, 15.0, *price.At(2).(*float64), "number cells are kept")
+
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		assert.Equal(t, 40.0, *frame.Fields[2].At(1).(*float64))
+		assert.Equal(t, "USD 1,234", sheet.Sheets[0].Data[0].RowData[1].Values[1].FormattedValue)
+	})
+
+	t.Run("columns are strings without stripping", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+	})
+
+	t.Run("text left as NaN or Inf is kept", func(t *testing.T) {
+		for _, text := range []string{"USD NaN", "inf USD", "USD -Infinity"} {
+			value := text
+			cell := numericStripCell([]string{"USD"})(&sheets.CellData{FormattedValue: value, EffectiveValue: &sheets.ExtendedValue{StringValue: &value}})
+			assert.Equal(t, text, cell.FormattedValue)
+			assert.Nil(t, cell.EffectiveValue.NumberValue, text)
+		}
+	})
+
+	t.Run("unknown columns are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", NumericStrip: map[string][]string{"Cost": {"USD"}}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `numeric strip column "Cost" not found`)
+	})
+}
@@ -0,0 +1,87 @@
+package googlesheets
+
+import (
+	"context"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxCellTypeRows limits the cellTypes debug frame to a small range.
+const maxCellTypeRows = 100
+
+// getCellTypes returns the data frame together with a parallel frame holding the Sheets value type of each cell.
+func (gs *GoogleSheets) getCellTypes(ctx context.Context, client client, refID string, qm *models.QueryModel) ([]*data.Frame, error) {
+	release, err := gs.acquireTransformSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	spreadsheet, meta, err := gs.getSheetData(ctx, client, qm)
+	if err != nil {
+		return nil, err
+	}
+	sheet := spreadsheet.Sheets[0]
+
+	source := &sourceCells{}
+	frame, err := gs.transformSheet(sheet, meta, refID, withSpreadsheetLocation(qm, spreadsheet), source)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*data.Frame{frame, getCellTypesFrame(source.columns, source.rows, refID)}, nil
+}
+
+// getCellTypesFrame returns a frame with a field per column of the data frame, so headers, selected columns
+// and skipped rows match it.
+func getCellTypesFrame(columns []*ColumnDefinition, rows []*sheets.RowData, refID string) *data.Frame {
+	if len(rows) > maxCellTypeRows {
+		rows = rows[:maxCellTypeRows]
+	}
+
+	frame := data.NewFrame("cellTypes")
+	frame.RefID = refID
+	for _, column := range columns {
+		types := make([]string, len(rows))
+		for i, row := range rows {
+			var cell *sheets.CellData
+			if column.ColumnIndex < len(row.Values) {
+				cell = row.Values[column.ColumnIndex]
+			}
+			types[i] = getCellType(cell)
+		}
+		frame.Fields = append(frame.Fields, data.NewField(column.Header, nil, types))
+	}
+	return frame
+}
+
+// getCellType returns the name of the Sheets value a cell holds.
+func getCellType(cell *sheets.CellData) string {
+	if cell == nil {
+		return "empty"
+	}
+
+	if cell.UserEnteredValue != nil && cell.UserEnteredValue.FormulaValue != nil {
+		return "formulaValue"
+	}
+
+	value := cell.EffectiveValue
+	if value == nil {
+		value = cell.UserEnteredValue
+	}
+	switch {
+	case value == nil:
+		return "empty"
+	case value.NumberValue != nil:
+		return "numberValue"
+	case value.StringValue != nil:
+		return "stringValue"
+	case value.BoolValue != nil:
+		return "boolValue"
+	case value.ErrorValue != nil:
+		return "errorValue"
+	}
+	return "empty"
+}
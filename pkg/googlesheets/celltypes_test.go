@@ -0,0 +1,66 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestCellTypes(t *testing.T) {
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+	qm := models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeCellTypes}
+
+	frames, err := gsd.getCellTypes(context.Background(), &fakeClient{}, "ref1", &qm)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(frames))
+
+	dataFrame, typesFrame := frames[0], frames[1]
+	require.Equal(t, len(dataFrame.Fields), len(typesFrame.Fields))
+	assert.Equal(t, "cellTypes", typesFrame.Name)
+	assert.Equal(t, dataFrame.Rows(), typesFrame.Rows())
+
+	field := fieldByName(t, typesFrame, "Number")
+	assert.Equal(t, "numberValue", field.At(0))
+
+	field = fieldByName(t, typesFrame, "SimpleString")
+	assert.Equal(t, "stringValue", field.At(0))
+
+	field = fieldByName(t, typesFrame, "NumberWithMissingValues")
+	assert.Equal(t, "empty", field.At(0))
+	assert.Equal(t, "numberValue", field.At(1))
+
+	field = fieldByName(t, typesFrame, "MixedDataTypes")
+	assert.Equal(t, "numberValue", field.At(0))
+	assert.Equal(t, "stringValue", field.At(7))
+
+	t.Run("the types frame has the columns of the data frame", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", QueryType: models.QueryTypeCellTypes, ColumnExcludeGlob: "Number*"}
+		frames, err := gsd.getCellTypes(context.Background(), &fakeClient{}, "ref1", &qm)
+		require.NoError(t, err)
+
+		dataFrame, typesFrame := frames[0], frames[1]
+		require.Equal(t, len(dataFrame.Fields), len(typesFrame.Fields))
+		for i, field := range dataFrame.Fields {
+			assert.Equal(t, field.Name, typesFrame.Fields[i].Name)
+			assert.NotContains(t, field.Name, "Number")
+		}
+		assert.Equal(t, "stringValue", fieldByName(t, typesFrame, "SimpleString").At(0))
+	})
+
+	t.Run("formulas and booleans", func(t *testing.T) {
+		formula := "=A1"
+		yes := true
+		assert.Equal(t, "formulaValue", getCellType(&sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{FormulaValue: &formula}}))
+		assert.Equal(t, "boolValue", getCellType(&sheets.CellData{EffectiveValue: &sheets.ExtendedValue{BoolValue: &yes}}))
+		assert.Equal(t, "errorValue", getCellType(&sheets.CellData{EffectiveValue: &sheets.ExtendedValue{ErrorValue: &sheets.ErrorValue{Type: "DIVIDE_BY_ZERO"}}}))
+		assert.Equal(t, "empty", getCellType(&sheets.CellData{}))
+	})
+}
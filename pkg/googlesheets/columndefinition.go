@@ -18,12 +18,17 @@ const (
 	ColumTypeString = "STRING"
 )
 
+// timeOfDayUnit is the unit of number columns holding the seconds since midnight of TIME formatted cells.
+const timeOfDayUnit = "clocks"
+
 // ColumnDefinition represents a spreadsheet column definition.
 type ColumnDefinition struct {
 	Header      string
 	ColumnIndex int
 	types       map[ColumnType]bool
 	units       map[string]bool
+	decimals    map[uint16]bool
+	forcedType  ColumnType
 }
 
 // NewColumnDefinition creates a new ColumnDefinition.
@@ -33,6 +38,7 @@ func NewColumnDefinition(header string, index int) *ColumnDefinition {
 		ColumnIndex: index,
 		types:       map[ColumnType]bool{},
 		units:       map[string]bool{},
+		decimals:    map[uint16]bool{},
 	}
 }
 
@@ -42,8 +48,17 @@ func (cd *ColumnDefinition) CheckCell(cell *sheets.CellData) {
 	cd.checkUnit(cell)
 }
 
+// ForceType overrides the detected type of a ColumnDefinition.
+func (cd *ColumnDefinition) ForceType(columnType ColumnType) {
+	cd.forcedType = columnType
+}
+
 // GetType gets the type of a ColumnDefinition.
 func (cd *ColumnDefinition) GetType() ColumnType {
+	if cd.forcedType != "" {
+		return cd.forcedType
+	}
+
 	if len(cd.types) == 1 {
 		for columnType := range cd.types {
 			return columnType
@@ -65,6 +80,17 @@ func (cd *ColumnDefinition) GetUnit() string {
 	return ""
 }
 
+// GetDecimals gets the number of decimals of a ColumnDefinition, or nil if its cells don't agree on one.
+func (cd *ColumnDefinition) GetDecimals() *uint16 {
+	if len(cd.decimals) == 1 {
+		for decimals := range cd.decimals {
+			return &decimals
+		}
+	}
+
+	return nil
+}
+
 // HasMixedTypes returns whether a ColumnDefinition has mixed types.
 func (cd *ColumnDefinition) HasMixedTypes() bool {
 	return len(cd.types) > 1
@@ -90,6 +116,12 @@ func (cd *ColumnDefinition) checkType(cell *sheets.CellData) {
 			cd.types["TIME"] = true
 			return
 		}
+		if cell.EffectiveFormat.NumberFormat.Type == "TIME" {
+			// A time of day is a fraction of a day rather than a date
+			cd.types["NUMBER"] = true
+			cd.units[timeOfDayUnit] = true
+			return
+		}
 	}
 
 	if hasNumberFormat || hasNumberValue || "0" == cell.FormattedValue {
@@ -125,6 +157,10 @@ func (cd *ColumnDefinition) checkUnit(cellData *sheets.CellData) {
 		return
 	}
 
+	if decimals, ok := patternDecimals(cellData.UserEnteredFormat.NumberFormat); ok {
+		cd.decimals[decimals] = true
+	}
+
 	switch cellData.UserEnteredFormat.NumberFormat.Type {
 	case "NUMBER":
 		for unit, unitID := range unitMappings {
@@ -0,0 +1,164 @@
+package googlesheets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// columnNameResolver maps the column names of query options to the header they match ignoring case.
+type columnNameResolver struct {
+	headers map[string]bool
+	folded  map[string][]string
+}
+
+func newColumnNameResolver(columns []*ColumnDefinition) *columnNameResolver {
+	r := &columnNameResolver{
+		headers: make(map[string]bool, len(columns)),
+		folded:  make(map[string][]string, len(columns)),
+	}
+	for _, column := range columns {
+		r.headers[column.Header] = true
+		key := strings.ToLower(column.Header)
+		r.folded[key] = append(r.folded[key], column.Header)
+	}
+	return r
+}
+
+// newFrameColumnNameResolver resolves names against the fields of a frame, for the options applied to the
+// frames of a query rather than to the columns of a range, such as Filter and JoinKeys.
+func newFrameColumnNameResolver(frame *data.Frame) *columnNameResolver {
+	columns := make([]*ColumnDefinition, len(frame.Fields))
+	for i, field := range frame.Fields {
+		columns[i] = NewColumnDefinition(field.Name, i)
+	}
+	return newColumnNameResolver(columns)
+}
+
+// resolve returns the header a name matches. An exact match wins, names without a match are returned unchanged
+// so that the option reports it as not found.
+func (r *columnNameResolver) resolve(name string) (string, error) {
+	if r.headers[name] {
+		return name, nil
+	}
+	matches := r.folded[strings.ToLower(name)]
+	switch len(matches) {
+	case 0:
+		return name, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("column %q is ambiguous ignoring case, it matches the headers %q", name, matches)
+	}
+}
+
+func (r *columnNameResolver) resolveAll(names []string) ([]string, error) {
+	if names == nil {
+		return nil, nil
+	}
+	resolved := make([]string, len(names))
+	for i, name := range names {
+		header, err := r.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = header
+	}
+	return resolved, nil
+}
+
+// resolveKey resolves a map key, rejecting keys that differ only in case since they would overwrite each other.
+func (r *columnNameResolver) resolveKey(name string, seen map[string]string) (string, error) {
+	header, err := r.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	if other, ok := seen[header]; ok {
+		return "", fmt.Errorf("columns %q and %q both match the header %q ignoring case", other, name, header)
+	}
+	seen[header] = name
+	return header, nil
+}
+
+// resolveColumnNames returns a copy of the query with the column names of its options replaced by the headers
+// they match ignoring case.
+func resolveColumnNames(qm *models.QueryModel, columns []*ColumnDefinition) (*models.QueryModel, error) {
+	r := newColumnNameResolver(columns)
+	resolved := *qm
+	var err error
+
+	for _, name := range []*string{&resolved.TimeColumn, &resolved.LabelColumn, &resolved.PivotColumn, &resolved.PivotValueColumn,
+		&resolved.LastEditedColumn, &resolved.GroupByColumn} {
+		if *name == "" {
+			continue
+		}
+		if *name, err = r.resolve(*name); err != nil {
+			return nil, err
+		}
+	}
+	for _, names := range []*[]string{&resolved.EpochSecondsColumns, &resolved.EpochMillisColumns, &resolved.PivotIndexColumns,
+		&resolved.BigNumberAsString, &resolved.ColumnOrder, &resolved.ForwardFillColumns} {
+		if *names, err = r.resolveAll(*names); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, options := range []*map[string]string{&resolved.ColumnTypes, &resolved.RegexFilter, &resolved.SplitColumns,
+		&resolved.Aggregations} {
+		if *options == nil {
+			continue
+		}
+		seen := map[string]string{}
+		byHeader := make(map[string]string, len(*options))
+		for name, value := range *options {
+			header, err := r.resolveKey(name, seen)
+			if err != nil {
+				return nil, err
+			}
+			byHeader[header] = value
+		}
+		*options = byHeader
+	}
+
+	if resolved.Scale != nil {
+		seen := map[string]string{}
+		scale := make(map[string]float64, len(resolved.Scale))
+		for name, value := range resolved.Scale {
+			header, err := r.resolveKey(name, seen)
+			if err != nil {
+				return nil, err
+			}
+			scale[header] = value
+		}
+		resolved.Scale = scale
+	}
+
+	if resolved.CoercionFallback != nil {
+		seen := map[string]string{}
+		fallback := make(map[string]interface{}, len(resolved.CoercionFallback))
+		for name, value := range resolved.CoercionFallback {
+			header, err := r.resolveKey(name, seen)
+			if err != nil {
+				return nil, err
+			}
+			fallback[header] = value
+		}
+		resolved.CoercionFallback = fallback
+	}
+
+	if resolved.NumericStrip != nil {
+		seen := map[string]string{}
+		strip := make(map[string][]string, len(resolved.NumericStrip))
+		for name, value := range resolved.NumericStrip {
+			header, err := r.resolveKey(name, seen)
+			if err != nil {
+				return nil, err
+			}
+			strip[header] = value
+		}
+		resolved.NumericStrip = strip
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,75 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseInsensitiveColumns(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/mixed-case-headers.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("options match headers ignoring case", func(t *testing.T) {
+		qm := models.QueryModel{
+			Spreadsheet:            "someid",
+			CaseInsensitiveColumns: true,
+			ColumnTypes:            map[string]string{"price": "string"},
+			BigNumberAsString:      []string{"ORDER ID"},
+			ColumnOrder:            []string{"region"},
+		}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, "Region", frame.Fields[0].Name)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, "1001", *frame.Fields[1].At(0).(*string))
+		price := frame.Fields[frameFieldIndex(frame, "Price")]
+		require.Equal(t, data.FieldTypeNullableString, price.Type())
+		assert.Equal(t, "12.5", *price.At(0).(*string))
+	})
+
+	t.Run("group by options match headers ignoring case", func(t *testing.T) {
+		qm := models.QueryModel{
+			Spreadsheet:            "someid",
+			CaseInsensitiveColumns: true,
+			GroupByColumn:          "region",
+			Aggregations:           map[string]string{"PRICE": "sum"},
+		}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Region", frame.Fields[0].Name)
+		assert.Equal(t, 2, frame.Rows())
+	})
+
+	t.Run("names must match exactly by default", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ColumnOrder: []string{"region"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.Error(t, err)
+	})
+
+	t.Run("headers differing only in case are ambiguous", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", CaseInsensitiveColumns: true, ColumnTypes: map[string]string{"price": "string"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[1], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `column "price" is ambiguous ignoring case, it matches the headers ["Price" "PRICE"]`)
+
+		qm.ColumnTypes = map[string]string{"PRICE": "string"}
+		_, err = gsd.transformSheetToDataFrame(sheet.Sheets[1], make(map[string]interface{}), "ref1", &qm)
+		assert.NoError(t, err, "exact matches are not ambiguous")
+	})
+
+	t.Run("names differing only in case can not match the same header", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", CaseInsensitiveColumns: true, Scale: map[string]float64{"Region": 1, "region": 2}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.Error(t, err)
+	})
+}
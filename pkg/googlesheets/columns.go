@@ -0,0 +1,145 @@
+package googlesheets
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func validateColumnGlobs(include, exclude string) error {
+	for _, pattern := range []string{include, exclude} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid column glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// selectColumns keeps the columns whose header matches the include glob and not the exclude glob.
+// An empty include glob matches every column, and exclude takes precedence over include.
+func selectColumns(columns []*ColumnDefinition, include, exclude string) ([]*ColumnDefinition, error) {
+	if err := validateColumnGlobs(include, exclude); err != nil {
+		return nil, err
+	}
+
+	selected := []*ColumnDefinition{}
+	for _, column := range columns {
+		if include != "" {
+			if ok, _ := path.Match(include, column.Header); !ok {
+				continue
+			}
+		}
+		if exclude != "" {
+			if ok, _ := path.Match(exclude, column.Header); ok {
+				continue
+			}
+		}
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// reorderFields moves the fields named in order to the front, in that order. The other fields follow in their original order.
+func reorderFields(frame *data.Frame, order []string) error {
+	listed := make(map[*data.Field]bool, len(order))
+	fields := make([]*data.Field, 0, len(frame.Fields))
+	for _, name := range order {
+		index := frameFieldIndex(frame, name)
+		if index < 0 {
+			return fmt.Errorf("column order column %q not found", name)
+		}
+		if !listed[frame.Fields[index]] {
+			listed[frame.Fields[index]] = true
+			fields = append(fields, frame.Fields[index])
+		}
+	}
+	for _, field := range frame.Fields {
+		if !listed[field] {
+			fields = append(fields, field)
+		}
+	}
+	frame.Fields = fields
+	return nil
+}
+
+// addConstantFields appends a string field with the same value in every row for each entry, sorted by name.
+// Sheet columns keep their names, so a constant field can not have the name of one.
+func addConstantFields(frame *data.Frame, constants map[string]string) error {
+	names := make([]string, 0, len(constants))
+	for name := range constants {
+		if frameFieldIndex(frame, name) >= 0 {
+			return fmt.Errorf("constant field %q has the name of a column", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rowCount := frame.Rows()
+	for _, name := range names {
+		value := constants[name]
+		values := make([]*string, rowCount)
+		for i := range values {
+			values[i] = &value
+		}
+		field := data.NewField(name, nil, values)
+		field.Config = &data.FieldConfig{DisplayName: name}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return nil
+}
+
+func validateSplitColumns(splitColumns map[string]string) error {
+	for column, delimiter := range splitColumns {
+		if delimiter == "" {
+			return fmt.Errorf("split column %q needs a delimiter", column)
+		}
+	}
+	return nil
+}
+
+// splitFields replaces each string field in splitColumns with one field per delimited part, named <column>_1, <column>_2
+// and so on. Rows with fewer parts are padded with null.
+func splitFields(frame *data.Frame, splitColumns map[string]string) error {
+	for column, delimiter := range splitColumns {
+		index := frameFieldIndex(frame, column)
+		if index < 0 {
+			return fmt.Errorf("split column %q not found", column)
+		}
+		field := frame.Fields[index]
+		if field.Type() != data.FieldTypeNullableString {
+			return fmt.Errorf("split column %q is not a string column", column)
+		}
+
+		parts := make([][]string, field.Len())
+		partCount := 0
+		for i := range parts {
+			if value, ok := field.ConcreteAt(i); ok {
+				parts[i] = strings.Split(value.(string), delimiter)
+				if len(parts[i]) > partCount {
+					partCount = len(parts[i])
+				}
+			}
+		}
+
+		partFields := make([]*data.Field, partCount)
+		for p := range partFields {
+			values := make([]*string, len(parts))
+			for i, rowParts := range parts {
+				if p < len(rowParts) {
+					values[i] = &rowParts[p]
+				}
+			}
+			name := fmt.Sprintf("%s_%d", column, p+1)
+			partFields[p] = data.NewField(name, field.Labels, values)
+			partFields[p].Config = &data.FieldConfig{DisplayName: name}
+		}
+
+		fields := append([]*data.Field{}, frame.Fields[:index]...)
+		fields = append(fields, partFields...)
+		frame.Fields = append(fields, frame.Fields[index+1:]...)
+	}
+	return nil
+}
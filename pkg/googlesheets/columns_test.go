@@ -0,0 +1,174 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnGlobs(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/wide-columns.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	fieldNames := func(t *testing.T, qm models.QueryModel) []string {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		names := []string{}
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	t.Run("include glob selects matching columns", func(t *testing.T) {
+		names := fieldNames(t, models.QueryModel{Spreadsheet: "someid", ColumnIncludeGlob: "metric_*"})
+		assert.Equal(t, []string{"metric_cpu", "metric_mem", "metric_disk"}, names)
+	})
+
+	t.Run("exclude glob drops matching columns", func(t *testing.T) {
+		names := fieldNames(t, models.QueryModel{Spreadsheet: "someid", ColumnExcludeGlob: "metric_*"})
+		assert.Equal(t, []string{"host", "note"}, names)
+	})
+
+	t.Run("exclude takes precedence over include", func(t *testing.T) {
+		names := fieldNames(t, models.QueryModel{Spreadsheet: "someid", ColumnIncludeGlob: "metric_*", ColumnExcludeGlob: "*_disk"})
+		assert.Equal(t, []string{"metric_cpu", "metric_mem"}, names)
+	})
+
+	t.Run("values follow their columns", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ColumnIncludeGlob: "metric_m?m"}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 1)
+		assert.Equal(t, 40.0, *frame.Fields[0].At(0).(*float64))
+		assert.Equal(t, 55.0, *frame.Fields[0].At(1).(*float64))
+	})
+
+	t.Run("invalid glob is rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{ColumnIncludeGlob: "metric_["}))
+	})
+}
+
+func TestScale(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/wide-columns.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	qm := models.QueryModel{Spreadsheet: "someid", Scale: map[string]float64{"metric_cpu": 1000, "host": 10}}
+	meta := make(map[string]interface{})
+	frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+	require.NoError(t, err)
+
+	cpu := fieldByName(t, frame, "metric_cpu")
+	assert.Equal(t, 12000.0, *cpu.At(0).(*float64))
+	assert.Equal(t, 30000.0, *cpu.At(1).(*float64))
+	assert.Equal(t, 40.0, *fieldByName(t, frame, "metric_mem").At(0).(*float64))
+	assert.Equal(t, "web-1", *fieldByName(t, frame, "host").At(0).(*string))
+	assert.Contains(t, meta["warnings"], `Column "host" is not numeric and can not be scaled`)
+}
+
+func TestColumnOrder(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/wide-columns.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("listed columns come first and the others follow in sheet order", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ColumnOrder: []string{"note", "metric_disk", "host"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		assert.Equal(t, []string{"note", "metric_disk", "host", "metric_cpu", "metric_mem"}, names)
+	})
+
+	t.Run("unknown columns are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ColumnOrder: []string{"missing"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `column order column "missing" not found`)
+	})
+}
+
+func TestConstantFields(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/wide-columns.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("constant fields are added to every row", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ConstantFields: map[string]string{"environment": "prod", "source": "sheet A"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, 7, len(frame.Fields))
+		environment, source := frame.Fields[5], frame.Fields[6]
+		assert.Equal(t, "environment", environment.Name)
+		assert.Equal(t, "source", source.Name)
+		require.Equal(t, frame.Rows(), environment.Len())
+		for i := 0; i < frame.Rows(); i++ {
+			assert.Equal(t, "prod", *environment.At(i).(*string))
+			assert.Equal(t, "sheet A", *source.At(i).(*string))
+		}
+	})
+
+	t.Run("constant fields can not replace columns", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ConstantFields: map[string]string{"host": "web-1"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `constant field "host" has the name of a column`)
+	})
+}
+
+func TestSplitColumns(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/packed-values.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("packed values are split into fields padded with null", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", SplitColumns: map[string]string{"Tags": ","}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, 4, len(frame.Fields))
+		assert.Equal(t, "Host", frame.Fields[0].Name)
+		assert.Equal(t, "Tags_1", frame.Fields[1].Name)
+		assert.Equal(t, "Tags_3", frame.Fields[3].Name)
+
+		assert.Equal(t, "eu", *frame.Fields[1].At(0).(*string))
+		assert.Equal(t, "prod", *frame.Fields[2].At(0).(*string))
+		assert.Equal(t, "frontend", *frame.Fields[3].At(0).(*string))
+		assert.Equal(t, "staging", *frame.Fields[2].At(1).(*string))
+		assert.Nil(t, frame.Fields[3].At(1))
+		assert.Nil(t, frame.Fields[1].At(2))
+	})
+
+	t.Run("unknown columns and empty delimiters are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", SplitColumns: map[string]string{"missing": ","}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `split column "missing" not found`)
+
+		assert.Error(t, validateQuery(&models.QueryModel{SplitColumns: map[string]string{"Tags": ""}}))
+	})
+}
@@ -0,0 +1,45 @@
+package googlesheets
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachConcurrently calls fn for every index in [0, n) with at most limit calls running at the same time.
+func forEachConcurrently(n int, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// acquireTransformSlot waits until fewer than MaxConcurrentTransforms sheets are being fetched and transformed.
+// The returned function releases the slot, calling it again does nothing.
+func (gs *GoogleSheets) acquireTransformSlot(ctx context.Context) (func(), error) {
+	if gs.MaxConcurrentTransforms <= 0 {
+		return func() {}, nil
+	}
+
+	gs.transformSlotsOnce.Do(func() {
+		gs.transformSlots = make(chan struct{}, gs.MaxConcurrentTransforms)
+	})
+	select {
+	case gs.transformSlots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-gs.transformSlots }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
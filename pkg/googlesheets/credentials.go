@@ -0,0 +1,85 @@
+package googlesheets
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/sheets/v4"
+)
+
+// requiredCredentialFields are the service account key fields needed to fetch a token.
+var requiredCredentialFields = []string{"client_email", "private_key", "token_uri"}
+
+// validateCredentials checks a service account JSON key by fetching a token with it, without reading any sheet.
+// The token is only fetched from tokenURL, a key whose token_uri differs is rejected, so that a key can't point
+// the backend at another host. It returns the offending field, if the problem can be attributed to one.
+func validateCredentials(ctx context.Context, credentials string, tokenURL string) (string, error) {
+	key := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(credentials), &key); err != nil {
+		return "", fmt.Errorf("service account key is not valid JSON: %w", err)
+	}
+	for _, field := range requiredCredentialFields {
+		if value, ok := key[field].(string); !ok || value == "" {
+			return field, fmt.Errorf("service account key is missing %q", field)
+		}
+	}
+	if key["token_uri"] != tokenURL {
+		return "token_uri", fmt.Errorf("service account key has token_uri %q, expected %q", key["token_uri"], tokenURL)
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(credentials), sheets.SpreadsheetsReadonlyScope)
+	if err != nil {
+		return "private_key", fmt.Errorf("error parsing JWT file: %w", err)
+	}
+	if err := parsePrivateKey(jwtConfig.PrivateKey); err != nil {
+		return "private_key", err
+	}
+	jwtConfig.TokenURL = tokenURL
+	if _, err := jwtConfig.TokenSource(ctx).Token(); err != nil {
+		return "", fmt.Errorf("unable to fetch a token: %w", err)
+	}
+	return "", nil
+}
+
+// credentialsFrame returns a frame with the result of validating the credentials of a query, or else the saved ones.
+func credentialsFrame(ctx context.Context, refID string, qm *models.QueryModel, config *models.DatasourceSettings) *data.Frame {
+	credentials := qm.Credentials
+	if credentials == "" {
+		credentials = config.JWT
+	}
+
+	message := ""
+	field, err := validateCredentials(ctx, credentials, google.JWTTokenURL)
+	if err != nil {
+		message = err.Error()
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("passed", nil, []bool{err == nil}),
+		data.NewField("field", nil, []string{field}),
+		data.NewField("message", nil, []string{message}),
+	)
+	frame.RefID = refID
+	return frame
+}
+
+// parsePrivateKey checks that the key is a PEM encoded PKCS#8 or PKCS#1 private key.
+func parsePrivateKey(key []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return fmt.Errorf("private key is not PEM encoded")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+	return nil
+}
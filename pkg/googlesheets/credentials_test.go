@@ -0,0 +1,96 @@
+package googlesheets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+
+	key := func(t *testing.T, without string, overrides map[string]string) string {
+		fields := map[string]string{
+			"type":         "service_account",
+			"client_email": "grafana@project.iam.gserviceaccount.com",
+			"private_key":  privateKeyPEM,
+			"token_uri":    server.URL,
+		}
+		delete(fields, without)
+		for k, v := range overrides {
+			fields[k] = v
+		}
+		body, err := json.Marshal(fields)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("valid key passes", func(t *testing.T) {
+		field, err := validateCredentials(context.Background(), key(t, "", nil), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, "", field)
+	})
+
+	t.Run("malformed JSON fails", func(t *testing.T) {
+		_, err := validateCredentials(context.Background(), `{"client_email": `, server.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing fields are reported", func(t *testing.T) {
+		for _, missing := range requiredCredentialFields {
+			field, err := validateCredentials(context.Background(), key(t, missing, nil), server.URL)
+			assert.Error(t, err)
+			assert.Equal(t, missing, field)
+		}
+	})
+
+	t.Run("invalid private key is reported", func(t *testing.T) {
+		field, err := validateCredentials(context.Background(), key(t, "", map[string]string{"private_key": "not a key"}), server.URL)
+		assert.Error(t, err)
+		assert.Equal(t, "private_key", field)
+	})
+
+	t.Run("a token_uri of another host is rejected", func(t *testing.T) {
+		requested := false
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = true
+		}))
+		defer other.Close()
+
+		field, err := validateCredentials(context.Background(), key(t, "", map[string]string{"token_uri": other.URL}), server.URL)
+		assert.Error(t, err)
+		assert.Equal(t, "token_uri", field)
+		assert.False(t, requested)
+	})
+
+	t.Run("frame holds the offending field of the provided key", func(t *testing.T) {
+		qm := models.QueryModel{QueryType: models.QueryTypeValidateCredentials, Credentials: key(t, "client_email", nil)}
+		frame := credentialsFrame(context.Background(), "A", &qm, &models.DatasourceSettings{JWT: key(t, "", nil)})
+		assert.Equal(t, false, frame.Fields[0].At(0))
+		assert.Equal(t, "client_email", frame.Fields[1].At(0))
+		assert.NotEmpty(t, frame.Fields[2].At(0))
+	})
+
+	t.Run("the saved key is checked without a provided one", func(t *testing.T) {
+		frame := credentialsFrame(context.Background(), "A", &models.QueryModel{}, &models.DatasourceSettings{JWT: key(t, "private_key", nil)})
+		assert.Equal(t, "private_key", frame.Fields[1].At(0))
+	})
+}
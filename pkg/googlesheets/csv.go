@@ -0,0 +1,127 @@
+package googlesheets
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxPublishedCSVBytes limits how much of a published CSV export is read.
+const maxPublishedCSVBytes = 50 << 20
+
+const publishedCSVWarning = "Data is read from a published CSV, formatting and metadata based features such as units and decimals are not available"
+
+// validatePublishedCSVURL only allows the Google Sheets publish to web links, so that queries can not make the backend fetch arbitrary URLs.
+func validatePublishedCSVURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host != "docs.google.com" {
+		return fmt.Errorf("invalid published CSV URL %q, expected a https://docs.google.com/ link", rawURL)
+	}
+	return nil
+}
+
+// queryPublishedCSV reads a sheet that is published to the web as CSV, which works without any API credentials.
+func (gs *GoogleSheets) queryPublishedCSV(ctx context.Context, refID string, qm *models.QueryModel, config *models.DatasourceSettings) (*data.Frame, error) {
+	cacheKey := "csv:" + qm.PublishedCSVURL
+	meta := map[string]interface{}{"hit": false}
+	var sheet *sheets.Sheet
+	if item, expires, found := gs.Cache.GetWithExpiration(cacheKey); found && qm.CacheDurationSeconds > 0 {
+		sheet = item.(*sheets.Sheet)
+		meta = map[string]interface{}{"hit": true, "expires": expires.Unix()}
+	} else {
+		var err error
+		sheet, err = fetchPublishedCSV(ctx, qm.PublishedCSVURL, config)
+		if err != nil {
+			return nil, err
+		}
+		if qm.CacheDurationSeconds > 0 {
+			gs.Cache.Set(cacheKey, sheet, jitterDuration(time.Duration(qm.CacheDurationSeconds)*time.Second, qm.CacheJitter))
+		}
+	}
+
+	frame, err := gs.transformSheetToDataFrame(sheet, meta, refID, qm)
+	if err != nil {
+		return nil, err
+	}
+	meta["warnings"] = append([]string{publishedCSVWarning}, meta["warnings"].([]string)...)
+	return frame, nil
+}
+
+func fetchPublishedCSV(ctx context.Context, csvURL string, config *models.DatasourceSettings) (*sheets.Sheet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, csvURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := http.DefaultClient
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch published CSV: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch published CSV: %s", resp.Status)
+	}
+	return parseCSVSheet(io.LimitReader(resp.Body, maxPublishedCSVBytes))
+}
+
+// parseCSVSheet turns CSV into grid data, so that it goes through the same type detection as API data.
+// Numbers and dates are recognized from their text, since a CSV has no number formats.
+func parseCSVSheet(r io.Reader) (*sheets.Sheet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid published CSV: %w", err)
+	}
+
+	rows := make([]*sheets.RowData, len(records))
+	for i, record := range records {
+		values := make([]*sheets.CellData, len(record))
+		for j, value := range record {
+			values[j] = csvCell(value, i == 0)
+		}
+		rows[i] = &sheets.RowData{Values: values}
+	}
+	return &sheets.Sheet{Data: []*sheets.GridData{{RowData: rows}}}, nil
+}
+
+func csvCell(value string, header bool) *sheets.CellData {
+	if value == "" {
+		return &sheets.CellData{}
+	}
+	cell := &sheets.CellData{FormattedValue: value}
+	if header {
+		cell.EffectiveValue = &sheets.ExtendedValue{StringValue: &value}
+		return cell
+	}
+
+	if number, ok := parseFiniteNumber(value); ok {
+		cell.EffectiveValue = &sheets.ExtendedValue{NumberValue: &number}
+		return cell
+	}
+	if _, err := dateparse.ParseStrict(value); err == nil {
+		cell.EffectiveValue = &sheets.ExtendedValue{StringValue: &value}
+		cell.EffectiveFormat = &sheets.CellFormat{NumberFormat: &sheets.NumberFormat{Type: "DATE_TIME"}}
+		return cell
+	}
+	cell.EffectiveValue = &sheets.ExtendedValue{StringValue: &value}
+	return cell
+}
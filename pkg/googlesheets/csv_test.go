@@ -0,0 +1,89 @@
+package googlesheets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const publishedCSV = `Date,Region,Sales,Note
+2021-01-01,north,10.5,ok
+2021-01-02,south,,"late, again"
+2021-01-03,east,7,
+`
+
+func TestPublishedCSV(t *testing.T) {
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("a CSV body is parsed with type detection", func(t *testing.T) {
+		sheet, err := parseCSVSheet(strings.NewReader(publishedCSV))
+		require.NoError(t, err)
+
+		frame, err := gsd.transformSheetToDataFrame(sheet, make(map[string]interface{}), "ref1", &models.QueryModel{})
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 4)
+		require.Equal(t, 3, frame.Rows())
+
+		assert.Equal(t, "Date", frame.Fields[0].Name)
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		assert.Equal(t, time.Date(2021, 1, 2, 0, 0, 0, 0, time.Local), *frame.Fields[0].At(1).(*time.Time))
+
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, "south", *frame.Fields[1].At(1).(*string))
+
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		assert.Equal(t, 10.5, *frame.Fields[2].At(0).(*float64))
+		assert.Nil(t, frame.Fields[2].At(1))
+
+		assert.Equal(t, "late, again", *frame.Fields[3].At(1).(*string))
+		assert.Nil(t, frame.Fields[3].At(2))
+	})
+
+	t.Run("NaN and Inf cells are text", func(t *testing.T) {
+		sheet, err := parseCSVSheet(strings.NewReader("Name,Value\na,NaN\nb,inf\nc,-Infinity\n"))
+		require.NoError(t, err)
+
+		frame, err := gsd.transformSheetToDataFrame(sheet, make(map[string]interface{}), "ref1", &models.QueryModel{})
+		require.NoError(t, err)
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, "inf", *frame.Fields[1].At(1).(*string))
+	})
+
+	t.Run("published CSVs are fetched without credentials and cached", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			_, _ = w.Write([]byte(publishedCSV))
+		}))
+		defer server.Close()
+
+		qm := models.QueryModel{PublishedCSVURL: server.URL + "/pub?output=csv", CacheDurationSeconds: 10}
+		frame, err := gsd.queryPublishedCSV(context.Background(), "ref1", &qm, &models.DatasourceSettings{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, frame.Rows())
+		meta := frame.Meta.Custom.(map[string]interface{})
+		assert.Equal(t, publishedCSVWarning, meta["warnings"].([]string)[0])
+
+		frame, err = gsd.queryPublishedCSV(context.Background(), "ref1", &qm, &models.DatasourceSettings{})
+		require.NoError(t, err)
+		assert.Equal(t, true, frame.Meta.Custom.(map[string]interface{})["hit"])
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("only Google Sheets links are allowed", func(t *testing.T) {
+		assert.NoError(t, validateQuery(&models.QueryModel{PublishedCSVURL: "https://docs.google.com/spreadsheets/d/e/abc/pub?output=csv"}))
+		assert.Error(t, validateQuery(&models.QueryModel{PublishedCSVURL: "http://docs.google.com/spreadsheets/d/e/abc/pub?output=csv"}))
+		assert.Error(t, validateQuery(&models.QueryModel{PublishedCSVURL: "https://169.254.169.254/latest"}))
+	})
+}
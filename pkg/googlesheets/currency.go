@@ -0,0 +1,115 @@
+package googlesheets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+func validateCurrencyNormalization(normalization models.CurrencyNormalization) error {
+	if normalization.Target == "" {
+		if len(normalization.Rates) > 0 {
+			return fmt.Errorf("currency rates require a target currency")
+		}
+		return nil
+	}
+	for currency, rate := range normalization.Rates {
+		if rate <= 0 {
+			return fmt.Errorf("rate of currency %q must be positive", currency)
+		}
+	}
+	return nil
+}
+
+// isCurrencyColumn checks if a number column only has currency units.
+func isCurrencyColumn(column *ColumnDefinition) bool {
+	if column.GetType() != ColumTypeNumber || len(column.units) == 0 {
+		return false
+	}
+	for unit := range column.units {
+		if !strings.HasPrefix(unit, "currency") {
+			return false
+		}
+	}
+	return true
+}
+
+// cellCurrency returns the currency code of a cell, e.g. "EUR", or "" if it has none. The longest matching
+// symbol wins, so that "R$" is a real rather than a rand.
+func cellCurrency(cell *sheets.CellData) string {
+	if cell == nil || cell.UserEnteredFormat == nil || cell.UserEnteredFormat.NumberFormat == nil {
+		return ""
+	}
+
+	// The same places as checkUnit looks for units
+	text := ""
+	switch cell.UserEnteredFormat.NumberFormat.Type {
+	case "NUMBER":
+		text = cell.UserEnteredFormat.NumberFormat.Pattern
+	case "CURRENCY":
+		text = cell.FormattedValue
+	default:
+		return ""
+	}
+
+	symbol := ""
+	for unit := range unitMappings {
+		if strings.Contains(text, unit) && len(unit) > len(symbol) {
+			symbol = unit
+		}
+	}
+	if symbol == "" {
+		return ""
+	}
+	return strings.TrimPrefix(unitMappings[symbol], "currency")
+}
+
+// normalizeCurrencyFields converts the amounts of the currency columns to the target currency with the rates,
+// the amount of the target currency one unit of a currency is worth. Amounts without a currency format are
+// taken to be in the target currency, amounts in currencies without a rate become null with a warning.
+func normalizeCurrencyFields(frame *data.Frame, rows []*sheets.RowData, columns []*ColumnDefinition, normalization models.CurrencyNormalization) []string {
+	warnings := []string{}
+	for fieldIndex, column := range columns {
+		if !isCurrencyColumn(column) {
+			continue
+		}
+		field := frame.Fields[fieldIndex]
+
+		unknown := map[string]bool{}
+		for rowIndex, row := range rows {
+			value, ok := field.ConcreteAt(rowIndex)
+			if !ok || column.ColumnIndex >= len(row.Values) {
+				continue
+			}
+			currency := cellCurrency(row.Values[column.ColumnIndex])
+			if currency == "" || currency == normalization.Target {
+				continue
+			}
+			rate, ok := normalization.Rates[currency]
+			if !ok {
+				unknown[currency] = true
+				field.Set(rowIndex, nil)
+				continue
+			}
+			converted := value.(float64) * rate
+			field.Set(rowIndex, &converted)
+		}
+
+		if field.Config != nil {
+			field.Config.Unit = "currency" + normalization.Target
+		}
+		currencies := make([]string, 0, len(unknown))
+		for currency := range unknown {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		for _, currency := range currencies {
+			warnings = append(warnings, fmt.Sprintf("No rate from %s to %s for column %q, its %s amounts are empty", currency, normalization.Target, column.Header, currency))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,56 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/currencies.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("mixed USD and EUR amounts are converted to USD", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", NormalizeCurrency: models.CurrencyNormalization{
+			Target: "USD",
+			Rates:  map[string]float64{"EUR": 1.1},
+		}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		amount := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, amount.Type())
+		assert.Equal(t, "currencyUSD", amount.Config.Unit)
+		assert.Equal(t, 12.0, *amount.At(0).(*float64))
+		assert.InDelta(t, 11.0, *amount.At(1).(*float64), 1e-9)
+		assert.Nil(t, amount.At(2), "GBP has no rate")
+		assert.Nil(t, amount.At(3))
+		assert.Equal(t, 3.5, *amount.At(4).(*float64))
+		assert.Equal(t, 7.0, *amount.At(5).(*float64), "amounts without a currency are in the target currency")
+		assert.Equal(t, []string{`No rate from GBP to USD for column "Amount", its GBP amounts are empty`}, meta["warnings"])
+	})
+
+	t.Run("mixed currencies have no unit by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "", frame.Fields[1].Config.Unit)
+		assert.Equal(t, 10.0, *frame.Fields[1].At(1).(*float64))
+		assert.Contains(t, meta["warnings"], `Multiple units found in column "Amount". Formatted value will be used`)
+	})
+
+	t.Run("invalid rates are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{NormalizeCurrency: models.CurrencyNormalization{Target: "USD", Rates: map[string]float64{"EUR": 0}}}))
+		assert.Error(t, validateQuery(&models.QueryModel{NormalizeCurrency: models.CurrencyNormalization{Rates: map[string]float64{"EUR": 1.1}}}))
+	})
+}
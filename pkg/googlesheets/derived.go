@@ -0,0 +1,458 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// exprType is the type of value an expression evaluates to.
+type exprType int
+
+const (
+	exprNumber exprType = iota
+	exprString
+)
+
+// exprEvaluator evaluates an expression for a row. Values are float64 or string, ok is false for null.
+type exprEvaluator func(row int) (value interface{}, ok bool)
+
+// exprNode is a parsed expression that is bound to the fields of a frame before it is evaluated.
+type exprNode interface {
+	bind(frame *data.Frame) (exprEvaluator, exprType, error)
+}
+
+func validateDerivedColumns(derivedColumns []models.DerivedColumn) error {
+	for _, derived := range derivedColumns {
+		if derived.Name == "" {
+			return fmt.Errorf("derived column %q needs a name", derived.Expression)
+		}
+		if _, err := parseExpression(derived.Expression); err != nil {
+			return fmt.Errorf("invalid expression for derived column %q: %w", derived.Name, err)
+		}
+	}
+	return nil
+}
+
+// addDerivedColumns appends a field per derived column, evaluated row by row. Later derived
+// columns can reference earlier ones. Rows where an operand is null or the result is not a number are null.
+func addDerivedColumns(frame *data.Frame, derivedColumns []models.DerivedColumn) error {
+	for _, derived := range derivedColumns {
+		if frameFieldIndex(frame, derived.Name) >= 0 {
+			return fmt.Errorf("derived column %q already exists", derived.Name)
+		}
+		node, err := parseExpression(derived.Expression)
+		if err != nil {
+			return fmt.Errorf("invalid expression for derived column %q: %w", derived.Name, err)
+		}
+		eval, resultType, err := node.bind(frame)
+		if err != nil {
+			return fmt.Errorf("invalid expression for derived column %q: %w", derived.Name, err)
+		}
+
+		var field *data.Field
+		if resultType == exprString {
+			field = data.NewField(derived.Name, nil, make([]*string, frame.Rows()))
+		} else {
+			field = data.NewField(derived.Name, nil, make([]*float64, frame.Rows()))
+		}
+		field.Config = &data.FieldConfig{DisplayName: derived.Name}
+		for row := 0; row < frame.Rows(); row++ {
+			value, ok := eval(row)
+			if !ok {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				field.Set(row, &v)
+			case float64:
+				if !math.IsNaN(v) && !math.IsInf(v, 0) {
+					field.Set(row, &v)
+				}
+			}
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return nil
+}
+
+type numberLiteral float64
+
+func (n numberLiteral) bind(*data.Frame) (exprEvaluator, exprType, error) {
+	return func(int) (interface{}, bool) { return float64(n), true }, exprNumber, nil
+}
+
+type stringLiteral string
+
+func (s stringLiteral) bind(*data.Frame) (exprEvaluator, exprType, error) {
+	return func(int) (interface{}, bool) { return string(s), true }, exprString, nil
+}
+
+type fieldRef string
+
+func (f fieldRef) bind(frame *data.Frame) (exprEvaluator, exprType, error) {
+	index := frameFieldIndex(frame, string(f))
+	if index < 0 {
+		return nil, 0, fmt.Errorf("column %q not found", string(f))
+	}
+	field := frame.Fields[index]
+
+	switch {
+	case field.Type().Numeric():
+		return func(row int) (interface{}, bool) {
+			value, ok := field.ConcreteAt(row)
+			if !ok {
+				return nil, false
+			}
+			switch v := value.(type) {
+			case float64:
+				return v, true
+			case int64:
+				return float64(v), true
+			}
+			return nil, false
+		}, exprNumber, nil
+	case field.Type() == data.FieldTypeString || field.Type() == data.FieldTypeNullableString:
+		return func(row int) (interface{}, bool) {
+			value, ok := field.ConcreteAt(row)
+			if !ok {
+				return nil, false
+			}
+			return value.(string), true
+		}, exprString, nil
+	}
+	return nil, 0, fmt.Errorf("column %q of type %s can not be used in expressions", string(f), field.Type())
+}
+
+type negation struct {
+	operand exprNode
+}
+
+func (n negation) bind(frame *data.Frame) (exprEvaluator, exprType, error) {
+	eval, operandType, err := n.operand.bind(frame)
+	if err != nil {
+		return nil, 0, err
+	}
+	if operandType != exprNumber {
+		return nil, 0, fmt.Errorf("can only negate numbers")
+	}
+	return func(row int) (interface{}, bool) {
+		value, ok := eval(row)
+		if !ok {
+			return nil, false
+		}
+		return -value.(float64), true
+	}, exprNumber, nil
+}
+
+type binaryOperation struct {
+	operator    rune
+	left, right exprNode
+}
+
+func (b binaryOperation) bind(frame *data.Frame) (exprEvaluator, exprType, error) {
+	left, leftType, err := b.left.bind(frame)
+	if err != nil {
+		return nil, 0, err
+	}
+	right, rightType, err := b.right.bind(frame)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if b.operator == '+' && (leftType == exprString || rightType == exprString) {
+		// Adding a string concatenates
+		return func(row int) (interface{}, bool) {
+			l, ok := left(row)
+			if !ok {
+				return nil, false
+			}
+			r, ok := right(row)
+			if !ok {
+				return nil, false
+			}
+			return formatExprValue(l) + formatExprValue(r), true
+		}, exprString, nil
+	}
+	if leftType != exprNumber || rightType != exprNumber {
+		return nil, 0, fmt.Errorf("operator %c needs numbers", b.operator)
+	}
+
+	return func(row int) (interface{}, bool) {
+		l, ok := left(row)
+		if !ok {
+			return nil, false
+		}
+		r, ok := right(row)
+		if !ok {
+			return nil, false
+		}
+		x, y := l.(float64), r.(float64)
+		switch b.operator {
+		case '+':
+			return x + y, true
+		case '-':
+			return x - y, true
+		case '*':
+			return x * y, true
+		default:
+			return x / y, true
+		}
+	}, exprNumber, nil
+}
+
+type functionCall struct {
+	name string
+	args []exprNode
+}
+
+// exprFunctions are the functions expressions can call, with the number of arguments they take (-1 for any).
+var exprFunctions = map[string]struct {
+	args       int
+	resultType exprType
+}{
+	"abs":    {1, exprNumber},
+	"round":  {1, exprNumber},
+	"min":    {-1, exprNumber},
+	"max":    {-1, exprNumber},
+	"concat": {-1, exprString},
+	"upper":  {1, exprString},
+	"lower":  {1, exprString},
+}
+
+func (f functionCall) bind(frame *data.Frame) (exprEvaluator, exprType, error) {
+	function := exprFunctions[f.name]
+	if len(f.args) == 0 || (function.args > 0 && len(f.args) != function.args) {
+		return nil, 0, fmt.Errorf("wrong number of arguments for %s", f.name)
+	}
+
+	args := make([]exprEvaluator, len(f.args))
+	for i, arg := range f.args {
+		eval, argType, err := arg.bind(frame)
+		if err != nil {
+			return nil, 0, err
+		}
+		if function.resultType == exprNumber && argType != exprNumber {
+			return nil, 0, fmt.Errorf("%s needs numbers", f.name)
+		}
+		args[i] = eval
+	}
+
+	return func(row int) (interface{}, bool) {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			value, ok := arg(row)
+			if !ok {
+				return nil, false
+			}
+			values[i] = value
+		}
+
+		switch f.name {
+		case "abs":
+			return math.Abs(values[0].(float64)), true
+		case "round":
+			return math.Round(values[0].(float64)), true
+		case "min", "max":
+			result := values[0].(float64)
+			for _, value := range values[1:] {
+				if f.name == "min" {
+					result = math.Min(result, value.(float64))
+				} else {
+					result = math.Max(result, value.(float64))
+				}
+			}
+			return result, true
+		case "upper":
+			return strings.ToUpper(formatExprValue(values[0])), true
+		case "lower":
+			return strings.ToLower(formatExprValue(values[0])), true
+		default:
+			var sb strings.Builder
+			for _, value := range values {
+				sb.WriteString(formatExprValue(value))
+			}
+			return sb.String(), true
+		}
+	}, function.resultType, nil
+}
+
+func formatExprValue(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return value.(string)
+}
+
+// exprParser is a recursive descent parser for expressions such as `[Unit Price] * qty + 1`.
+// Column names that are not plain identifiers are written in square brackets.
+type exprParser struct {
+	input []rune
+	pos   int
+}
+
+func parseExpression(expression string) (exprNode, error) {
+	p := &exprParser{input: []rune(expression)}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// accept consumes the next rune if it is one of the given ones.
+func (p *exprParser) accept(runes string) (rune, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && strings.ContainsRune(runes, p.input[p.pos]) {
+		p.pos++
+		return p.input[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	node, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept("+-")
+		if !ok {
+			return node, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		node = binaryOperation{operator: operator, left: node, right: right}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept("*/")
+		if !ok {
+			return node, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		node = binaryOperation{operator: operator, left: node, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negation{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	start := p.pos
+	r := p.input[p.pos]
+	switch {
+	case r == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return node, nil
+	case r == '[':
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != ']' {
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("missing closing bracket at position %d", start+1)
+		}
+		p.pos++
+		return fieldRef(string(p.input[start+1 : p.pos-1])), nil
+	case r == '"' || r == '\'':
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != r {
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("unterminated string at position %d", start+1)
+		}
+		p.pos++
+		return stringLiteral(string(p.input[start+1 : p.pos-1])), nil
+	case unicode.IsDigit(r) || r == '.':
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		number, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", string(p.input[start:p.pos]))
+		}
+		return numberLiteral(number), nil
+	case unicode.IsLetter(r) || r == '_':
+		for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+			p.pos++
+		}
+		name := string(p.input[start:p.pos])
+		if _, ok := p.accept("("); !ok {
+			return fieldRef(name), nil
+		}
+		return p.parseCall(strings.ToLower(name))
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", r, start+1)
+}
+
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	if _, ok := exprFunctions[name]; !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	call := functionCall{name: name}
+	if _, ok := p.accept(")"); ok {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if _, ok := p.accept(")"); ok {
+			return call, nil
+		}
+		if _, ok := p.accept(","); !ok {
+			return nil, fmt.Errorf("expected , or ) in call to %s", name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivedColumns(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/orders.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+	transform := func(derived ...models.DerivedColumn) (*data.Frame, error) {
+		qm := models.QueryModel{Spreadsheet: "someid", DerivedColumns: derived}
+		return gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+	}
+
+	t.Run("arithmetic derivations are numeric", func(t *testing.T) {
+		frame, err := transform(
+			models.DerivedColumn{Name: "Total", Expression: "[Unit Price] * qty"},
+			models.DerivedColumn{Name: "Discounted", Expression: "max(Total - 1, 0) / 2"},
+			models.DerivedColumn{Name: "Negated", Expression: "-round([Unit Price])"},
+		)
+		require.NoError(t, err)
+
+		total := fieldByName(t, frame, "Total")
+		require.Equal(t, data.FieldTypeNullableFloat64, total.Type())
+		assert.Equal(t, 6.0, *total.At(0).(*float64))
+		assert.Nil(t, total.At(1))
+		assert.Equal(t, 2.5, *total.At(2).(*float64))
+
+		discounted := fieldByName(t, frame, "Discounted")
+		assert.Equal(t, 2.5, *discounted.At(0).(*float64))
+		assert.Equal(t, 0.75, *discounted.At(2).(*float64))
+
+		assert.Equal(t, -2.0, *fieldByName(t, frame, "Negated").At(1).(*float64))
+	})
+
+	t.Run("string concatenation derivations are strings", func(t *testing.T) {
+		frame, err := transform(
+			models.DerivedColumn{Name: "Label", Expression: `upper(Product) + " x" + qty`},
+			models.DerivedColumn{Name: "Code", Expression: "concat(Product, '-', [Unit Price])"},
+		)
+		require.NoError(t, err)
+
+		label := fieldByName(t, frame, "Label")
+		require.Equal(t, data.FieldTypeNullableString, label.Type())
+		assert.Equal(t, "APPLE x4", *label.At(0).(*string))
+		assert.Nil(t, label.At(1))
+		assert.Equal(t, "plum-0.25", *fieldByName(t, frame, "Code").At(2).(*string))
+	})
+
+	t.Run("invalid expressions are rejected", func(t *testing.T) {
+		_, err := transform(models.DerivedColumn{Name: "Total", Expression: "Product * qty"})
+		assert.Error(t, err)
+		_, err = transform(models.DerivedColumn{Name: "Total", Expression: "price * qty"})
+		assert.Error(t, err)
+		_, err = transform(models.DerivedColumn{Name: "qty", Expression: "qty * 2"})
+		assert.Error(t, err)
+
+		for _, expression := range []string{"qty *", "(qty", "[Unit Price", "sqrt(qty)", "qty qty", `"open`} {
+			assert.Error(t, validateQuery(&models.QueryModel{DerivedColumns: []models.DerivedColumn{{Name: "x", Expression: expression}}}), expression)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// applyEpochColumns marks the numeric epoch columns as time columns and returns the unit of each.
+func applyEpochColumns(columns []*ColumnDefinition, secondsColumns []string, millisColumns []string) (map[*ColumnDefinition]time.Duration, error) {
+	units := map[*ColumnDefinition]time.Duration{}
+	for unit, headers := range map[time.Duration][]string{time.Second: secondsColumns, time.Millisecond: millisColumns} {
+		for _, header := range headers {
+			column := findColumn(columns, header)
+			if column == nil {
+				return nil, fmt.Errorf("epoch column %q not found", header)
+			}
+			column.ForceType(ColumTypeTime)
+			units[column] = unit
+		}
+	}
+	return units, nil
+}
+
+// newEpochConverter converts numeric epoch values in the given unit to time, or to epoch milliseconds when asMillis is set.
+func newEpochConverter(unit time.Duration, asMillis bool) data.FieldConverter {
+	outputType := data.FieldTypeNullableTime
+	if asMillis {
+		outputType = data.FieldTypeNullableInt64
+	}
+
+	return data.FieldConverter{
+		OutputFieldType: outputType,
+		Converter: func(i interface{}) (interface{}, error) {
+			cellData, ok := i.(*sheets.CellData)
+			if !ok {
+				return nil, fmt.Errorf("expected type *sheets.CellData, but got %T", i)
+			}
+			if cellData.EffectiveValue == nil || cellData.EffectiveValue.NumberValue == nil {
+				return nil, fmt.Errorf("expected numeric epoch value but got '%v'", cellData.FormattedValue)
+			}
+
+			// Split off the fraction, nanoseconds since the epoch don't fit a float64 exactly
+			whole, fraction := math.Modf(*cellData.EffectiveValue.NumberValue)
+			t := time.Unix(0, int64(whole)*int64(unit)+int64(fraction*float64(unit)))
+			if asMillis {
+				millis := t.UnixNano() / int64(time.Millisecond)
+				return &millis, nil
+			}
+			return &t, nil
+		},
+	}
+}
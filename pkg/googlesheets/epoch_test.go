@@ -0,0 +1,54 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpochColumns(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/epoch.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("seconds and millis columns are read as time", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", EpochSecondsColumns: []string{"Seconds"}, EpochMillisColumns: []string{"Millis"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		seconds := fieldByName(t, frame, "Seconds")
+		require.Equal(t, data.FieldTypeNullableTime, seconds.Type())
+		assert.True(t, time.Unix(1700000060, 0).Equal(*seconds.At(1).(*time.Time)))
+
+		millis := fieldByName(t, frame, "Millis")
+		require.Equal(t, data.FieldTypeNullableTime, millis.Type())
+		assert.True(t, time.Unix(1700000000, 123*int64(time.Millisecond)).Equal(*millis.At(0).(*time.Time)))
+
+		assert.Equal(t, data.FieldTypeNullableFloat64, fieldByName(t, frame, "Value").Type())
+		assert.Equal(t, 0, findTimeField(frame))
+	})
+
+	t.Run("epoch columns can be emitted as epoch millis", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", EpochSecondsColumns: []string{"Seconds"}, TimeAsEpochMillis: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1700000000000), *fieldByName(t, frame, "Seconds").At(0).(*int64))
+	})
+
+	t.Run("missing epoch column is an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", EpochSecondsColumns: []string{"Timestamp"}}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCellPolicy(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/error-cells.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("error cells are null by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+		assert.Equal(t, 0.5, *field.At(0).(*float64))
+		assert.Nil(t, field.At(1))
+		assert.Nil(t, field.At(2))
+		assert.Equal(t, 2.0, *field.At(3).(*float64))
+		assert.Empty(t, meta["warnings"])
+	})
+
+	t.Run("error cells can be kept as strings", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ErrorCellPolicy: "keepString"}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableString, field.Type())
+		assert.Equal(t, "#DIV/0!", *field.At(1).(*string))
+		assert.Equal(t, "#N/A", *field.At(2).(*string))
+	})
+
+	t.Run("error cells can fail the query", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ErrorCellPolicy: "error"}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `cell B3 in column "Ratio" has the error #DIV/0!: Function DIVIDE parameter 2 cannot be zero.`)
+	})
+
+	t.Run("unknown policies are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{ErrorCellPolicy: "zero"}))
+	})
+}
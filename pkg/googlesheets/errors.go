@@ -0,0 +1,56 @@
+package googlesheets
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrEmptyRange is returned for a range without data rows when the query has ErrorOnEmpty set.
+var ErrEmptyRange = errors.New("range not found or empty")
+
+// isTransientError checks if an API error is likely to go away by itself, e.g. rate limiting or an outage.
+func isTransientError(err error) bool {
+	// A cancelled or timed out query context only concerns that query and must not be cached for others
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isOutOfBoundsError checks if the Sheets API rejected a range that lies outside of the sheet.
+func isOutOfBoundsError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "exceeds grid limits")
+}
+
+// noticeFrame returns an empty frame carrying a warning, for problems a user can fix without the query failing.
+func noticeFrame(refID string, text string) *data.Frame {
+	frame := data.NewFrame(refID)
+	frame.RefID = refID
+	frame.AppendNotices(data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     text,
+	})
+	return frame
+}
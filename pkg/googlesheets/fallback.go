@@ -0,0 +1,63 @@
+package googlesheets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// applyCoercionFallbackTypes makes mixed number and string columns with a numeric fallback number columns,
+// so that their strings become the fallback rather than the whole column falling back to strings.
+func applyCoercionFallbackTypes(columns []*ColumnDefinition, fallbacks map[string]interface{}) error {
+	for header, fallback := range fallbacks {
+		column := findColumn(columns, header)
+		if column == nil {
+			return fmt.Errorf("coercion fallback column %q not found", header)
+		}
+		if _, ok := fallback.(float64); ok && column.forcedType == "" && len(column.types) == 2 &&
+			column.types[ColumTypeNumber] && column.types[ColumTypeString] {
+			column.ForceType(ColumTypeNumber)
+		}
+	}
+	return nil
+}
+
+// coercionFallbackValues converts the fallbacks to values of the type of their column's field, by field index.
+func coercionFallbackValues(frame *data.Frame, columns []*ColumnDefinition, fallbacks map[string]interface{}) (map[int]interface{}, error) {
+	values := make(map[int]interface{}, len(fallbacks))
+	for i, column := range columns {
+		fallback, ok := fallbacks[column.Header]
+		if !ok {
+			continue
+		}
+
+		var value interface{}
+		switch frame.Fields[i].Type() {
+		case data.FieldTypeNullableFloat64:
+			if number, ok := fallback.(float64); ok {
+				value = &number
+			}
+		case data.FieldTypeNullableInt64:
+			if number, ok := fallback.(float64); ok {
+				millis := int64(number)
+				value = &millis
+			}
+		case data.FieldTypeNullableString:
+			if s, ok := fallback.(string); ok {
+				value = &s
+			}
+		case data.FieldTypeNullableTime:
+			if s, ok := fallback.(string); ok {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					value = &t
+				}
+			}
+		}
+		if value == nil {
+			return nil, fmt.Errorf("invalid coercion fallback %v for %s column %q", fallback, column.GetType(), column.Header)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,65 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoercionFallback(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/coercion.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("cells that don't fit a number column use the fallback", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{
+			Spreadsheet:      "someid",
+			ColumnTypes:      map[string]string{"Value": "number"},
+			CoercionFallback: map[string]interface{}{"Value": -1.0},
+		}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+		assert.Equal(t, 1.0, *field.At(0).(*float64))
+		assert.Equal(t, -1.0, *field.At(1).(*float64))
+		assert.Equal(t, 4.0, *field.At(2).(*float64))
+		assert.Equal(t, []string{`Used the fallback value for 1 cells in column "Value"`}, meta["warnings"])
+	})
+
+	t.Run("a numeric fallback keeps mixed columns numeric", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", CoercionFallback: map[string]interface{}{"Value": -1.0}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		assert.Equal(t, -1.0, *frame.Fields[1].At(1).(*float64))
+	})
+
+	t.Run("mixed columns are strings without a fallback", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+	})
+
+	t.Run("fallbacks must match the column type", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", CoercionFallback: map[string]interface{}{"Label": -1.0}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `invalid coercion fallback -1 for STRING column "Label"`)
+
+		qm = models.QueryModel{Spreadsheet: "someid", CoercionFallback: map[string]interface{}{"Missing": ""}}
+		_, err = gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `coercion fallback column "Missing" not found`)
+	})
+}
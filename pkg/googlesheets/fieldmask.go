@@ -0,0 +1,37 @@
+package googlesheets
+
+import (
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+)
+
+// cellFields are the cell properties every data query needs: the values and the number formats used for type and unit detection.
+var cellFields = []string{
+	"formattedValue",
+	"effectiveValue",
+	"effectiveFormat.numberFormat",
+	"userEnteredFormat.numberFormat",
+}
+
+// gridDataFields returns the field mask of a grid data fetch, narrowed down to what the query uses.
+// An empty mask requests every field.
+func gridDataFields(qm *models.QueryModel) string {
+	if qm.QueryType == models.QueryTypeCellTypes {
+		// The debug query reports the raw values of every cell
+		return ""
+	}
+
+	fields := cellFields
+	if qm.ExtractImages || qm.IncludeColumnFormulas {
+		// IMAGE() URLs and column formulas are read from the formula
+		fields = append(fields[:len(fields):len(fields)], "userEnteredValue")
+	}
+
+	gridFields := "startRow,startColumn,rowData.values(" + strings.Join(fields, ",") + ")"
+	if qm.RespectHiddenRows {
+		gridFields += ",rowMetadata.hiddenByFilter"
+	}
+
+	return "spreadsheetId,properties(timeZone,defaultFormat.numberFormat),sheets(properties,data(" + gridFields + "))"
+}
@@ -0,0 +1,55 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridDataFields(t *testing.T) {
+	t.Run("data-only queries request values and number formats only", func(t *testing.T) {
+		client := &fakeClient{}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O"}
+		_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+		require.NoError(t, err)
+
+		require.Len(t, client.requestedFields, 1)
+		assert.Equal(t, "spreadsheetId,properties(timeZone,defaultFormat.numberFormat),sheets(properties,data(startRow,startColumn,"+
+			"rowData.values(formattedValue,effectiveValue,effectiveFormat.numberFormat,userEnteredFormat.numberFormat)))", client.requestedFields[0])
+	})
+
+	t.Run("image extraction adds the entered values", func(t *testing.T) {
+		fields := gridDataFields(&models.QueryModel{ExtractImages: true})
+		assert.Contains(t, fields, "userEnteredValue")
+		assert.NotContains(t, gridDataFields(&models.QueryModel{}), "userEnteredValue")
+	})
+
+	t.Run("cell types query requests every field", func(t *testing.T) {
+		assert.Equal(t, "", gridDataFields(&models.QueryModel{QueryType: models.QueryTypeCellTypes}))
+	})
+
+	t.Run("queries with different masks are cached separately", func(t *testing.T) {
+		client := &fakeClient{}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O", CacheDurationSeconds: 10}
+		_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+		require.NoError(t, err)
+		qm.ExtractImages = true
+		_, meta, err := gsd.getSheetData(context.Background(), client, &qm)
+		require.NoError(t, err)
+		assert.False(t, meta["hit"].(bool))
+		assert.Equal(t, 2, client.getSpreadsheetCalls)
+	})
+}
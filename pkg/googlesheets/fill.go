@@ -0,0 +1,32 @@
+package googlesheets
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// forwardFillFields replaces the null values of the named fields with the last value above them.
+// Nulls before the first value stay null.
+func forwardFillFields(frame *data.Frame, columns []string) error {
+	for _, column := range columns {
+		index := frameFieldIndex(frame, column)
+		if index < 0 {
+			return fmt.Errorf("forward fill column %q not found", column)
+		}
+		field := frame.Fields[index]
+		if !field.Nullable() {
+			continue
+		}
+
+		last := -1
+		for i := 0; i < field.Len(); i++ {
+			if _, ok := field.ConcreteAt(i); ok {
+				last = i
+			} else if last >= 0 {
+				field.Set(i, field.CopyAt(last))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardFillColumns(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/sparse.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	values := func(t *testing.T, qm models.QueryModel) []interface{} {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+		region := []interface{}{}
+		for i := 0; i < frame.Rows(); i++ {
+			if value, ok := frame.Fields[0].ConcreteAt(i); ok {
+				region = append(region, value)
+			} else {
+				region = append(region, nil)
+			}
+		}
+		return region
+	}
+
+	t.Run("empty cells get the value above them", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ForwardFillColumns: []string{"Region"}}
+		assert.Equal(t, []interface{}{nil, "EMEA", "EMEA", "EMEA", "APAC", "APAC", "APAC"}, values(t, qm))
+
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+		assert.Nil(t, frame.Fields[1].At(5), "other columns are not filled")
+	})
+
+	t.Run("filling follows the row order of the frame", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ForwardFillColumns: []string{"Region"}, ReverseRows: true}
+		assert.Equal(t, []interface{}{nil, nil, "APAC", "APAC", "APAC", "EMEA", "EMEA"}, values(t, qm))
+	})
+
+	t.Run("empty cells stay null by default", func(t *testing.T) {
+		assert.Equal(t, []interface{}{nil, "EMEA", nil, nil, "APAC", nil, nil}, values(t, models.QueryModel{Spreadsheet: "someid"}))
+	})
+
+	t.Run("unknown columns are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ForwardFillColumns: []string{"missing"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `forward fill column "missing" not found`)
+	})
+}
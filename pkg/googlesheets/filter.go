@@ -0,0 +1,96 @@
+package googlesheets
+
+import (
+	"fmt"
+	"regexp"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func compileRegexFilters(filters map[string]string) (map[string]*regexp.Regexp, error) {
+	compiled := make(map[string]*regexp.Regexp, len(filters))
+	for column, pattern := range filters {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex filter for column %q: %w", column, err)
+		}
+		compiled[column] = re
+	}
+	return compiled, nil
+}
+
+// filterRowsByRegex keeps the rows where the formatted value of every filtered column matches its regex.
+// onDrop is called with the reason for every row that is dropped.
+func filterRowsByRegex(rows []*sheets.RowData, columns []*ColumnDefinition, filters map[string]string, onDrop func(*sheets.RowData, string)) ([]*sheets.RowData, error) {
+	compiled, err := compileRegexFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	filterByColumn := map[*ColumnDefinition]*regexp.Regexp{}
+	for name, re := range compiled {
+		column := findColumn(columns, name)
+		if column == nil {
+			return nil, fmt.Errorf("regex filter column %q not found", name)
+		}
+		filterByColumn[column] = re
+	}
+
+	filtered := make([]*sheets.RowData, 0, len(rows))
+	for _, row := range rows {
+		if column := firstMismatch(row, filterByColumn); column != nil {
+			onDrop(row, fmt.Sprintf("value in column %q does not match regex filter", column.Header))
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered, nil
+}
+
+// firstMismatch returns the first column whose value does not match its filter, or nil if all match.
+func firstMismatch(row *sheets.RowData, filterByColumn map[*ColumnDefinition]*regexp.Regexp) *ColumnDefinition {
+	for column, re := range filterByColumn {
+		value := ""
+		if column.ColumnIndex < len(row.Values) && row.Values[column.ColumnIndex] != nil {
+			value = row.Values[column.ColumnIndex].FormattedValue
+		}
+		if !re.MatchString(value) {
+			return column
+		}
+	}
+	return nil
+}
+
+// dropHiddenRows removes the rows from start onwards that are hidden by a filter or slicer in the sheet.
+func dropHiddenRows(grid *sheets.GridData, start int) []*sheets.RowData {
+	rows := []*sheets.RowData{}
+	for i, row := range grid.RowData[start:] {
+		index := start + i
+		if index < len(grid.RowMetadata) && grid.RowMetadata[index] != nil && grid.RowMetadata[index].HiddenByFilter {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// dropEmptyRows removes the rows whose cells all display nothing and returns how many were removed.
+func dropEmptyRows(rows []*sheets.RowData) ([]*sheets.RowData, int) {
+	kept := make([]*sheets.RowData, 0, len(rows))
+	for _, row := range rows {
+		if isEmptyRow(row) {
+			continue
+		}
+		kept = append(kept, row)
+	}
+	return kept, len(rows) - len(kept)
+}
+
+func isEmptyRow(row *sheets.RowData) bool {
+	for _, cell := range row.Values {
+		if cell != nil && cell.FormattedValue != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexFilter(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/logs.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("rows not matching the pattern are dropped", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Message": "timeout"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Fields[1].Len())
+		assert.Equal(t, "Connection timeout to db-1", *frame.Fields[1].At(0).(*string))
+		assert.Equal(t, "Retrying after timeout", *frame.Fields[1].At(1).(*string))
+	})
+
+	t.Run("case-insensitive flag is supported", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Level": "(?i)^error$"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Fields[0].Len())
+		assert.Equal(t, "ERROR", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "Error", *frame.Fields[0].At(1).(*string))
+	})
+
+	t.Run("every filter has to match", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Level": "(?i)^error$", "Message": "timeout"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Fields[0].Len())
+	})
+
+	t.Run("unknown column returns an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Host": "db"}}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid regex returns an error before fetching", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Message": "(timeout"}}
+		dr := gsd.Query(context.Background(), "ref1", &qm, &models.DatasourceSettings{}, backend.TimeRange{})
+		require.Error(t, dr.Error)
+		assert.Contains(t, dr.Error.Error(), "invalid regex filter")
+	})
+}
+
+func TestRowErrors(t *testing.T) {
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("rows failing coercion are reported", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/label-series.json")
+		require.NoError(t, err)
+
+		qm := models.QueryModel{Spreadsheet: "someid", LabelColumn: "Region", CollectRowErrors: true}
+		meta := make(map[string]interface{})
+		_, err = gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		rowErrors := meta["rowErrors"].([]rowError)
+		require.Equal(t, 2, len(rowErrors))
+		assert.Equal(t, 3, rowErrors[0].Row)
+		assert.Contains(t, rowErrors[0].Reason, `column "Q2"`)
+		assert.Equal(t, 5, rowErrors[1].Row)
+		assert.Contains(t, rowErrors[1].Reason, `column "Q1"`)
+	})
+
+	t.Run("rows dropped by filters are reported", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/logs.json")
+		require.NoError(t, err)
+
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Level": "(?i)^error$"}, CollectRowErrors: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 2, frame.Fields[0].Len())
+
+		rowErrors := meta["rowErrors"].([]rowError)
+		require.Equal(t, 3, len(rowErrors))
+		assert.Equal(t, []int{3, 5, 6}, []int{rowErrors[0].Row, rowErrors[1].Row, rowErrors[2].Row})
+		assert.Equal(t, `value in column "Level" does not match regex filter`, rowErrors[0].Reason)
+	})
+
+	t.Run("row errors are not collected by default", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/logs.json")
+		require.NoError(t, err)
+
+		qm := models.QueryModel{Spreadsheet: "someid", RegexFilter: map[string]string{"Level": "(?i)^error$"}}
+		meta := make(map[string]interface{})
+		_, err = gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.NotContains(t, meta, "rowErrors")
+	})
+}
+
+func TestRespectHiddenRows(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/filtered-rows.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("hidden rows are kept by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, 4, frame.Rows())
+	})
+
+	t.Run("rows hidden by a filter are dropped", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid", RespectHiddenRows: true})
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "North", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "East", *frame.Fields[0].At(1).(*string))
+	})
+
+	t.Run("row metadata is requested", func(t *testing.T) {
+		assert.Contains(t, gridDataFields(&models.QueryModel{RespectHiddenRows: true}), "rowMetadata.hiddenByFilter")
+	})
+}
+
+func TestSkipEmptyRows(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/blank-rows.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("rows without values are dropped", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", SkipEmptyRows: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, 4, frame.Rows())
+		assert.Equal(t, "Blue", *frame.Fields[0].At(1).(*string))
+		assert.Equal(t, "Green", *frame.Fields[0].At(2).(*string))
+		assert.Nil(t, frame.Fields[1].At(2))
+		assert.Nil(t, frame.Fields[0].At(3))
+		assert.Equal(t, 7.0, *frame.Fields[1].At(3).(*float64))
+		assert.Equal(t, 4, meta["skippedEmptyRows"])
+	})
+
+	t.Run("empty rows are kept by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 8, frame.Rows())
+		assert.NotContains(t, meta, "skippedEmptyRows")
+	})
+}
@@ -0,0 +1,27 @@
+package googlesheets
+
+import (
+	"google.golang.org/api/sheets/v4"
+)
+
+// getColumnFormulas maps the header of every formula-backed column to the formula of its first non-empty cell.
+// Columns whose first value was typed in rather than calculated are left out.
+func getColumnFormulas(rows []*sheets.RowData, columns []*ColumnDefinition) map[string]string {
+	formulas := map[string]string{}
+	for _, column := range columns {
+		for _, row := range rows {
+			if column.ColumnIndex >= len(row.Values) {
+				continue
+			}
+			cell := row.Values[column.ColumnIndex]
+			if cell == nil || cell.UserEnteredValue == nil {
+				continue
+			}
+			if cell.UserEnteredValue.FormulaValue != nil {
+				formulas[column.Header] = *cell.UserEnteredValue.FormulaValue
+			}
+			break
+		}
+	}
+	return formulas
+}
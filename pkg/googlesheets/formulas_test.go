@@ -0,0 +1,41 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnFormulas(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/formulas.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("formulas of formula-backed columns are added to the meta", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", IncludeColumnFormulas: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"Total": "=A3*B3", "Label": `=IF(C3>5,"big","small")`}, meta["columnFormulas"])
+		assert.Equal(t, 6.0, *fieldByName(t, frame, "Total").At(1).(*float64))
+		assert.Equal(t, "big", *fieldByName(t, frame, "Label").At(1).(*string))
+	})
+
+	t.Run("formulas are not reported by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.NotContains(t, meta, "columnFormulas")
+	})
+
+	t.Run("the entered values are requested", func(t *testing.T) {
+		assert.Contains(t, gridDataFields(&models.QueryModel{IncludeColumnFormulas: true}), "userEnteredValue")
+	})
+}
@@ -0,0 +1,48 @@
+package googlesheets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// applyLastEditedColumn forces the column holding the time each row was last edited to the time type.
+func applyLastEditedColumn(columns []*ColumnDefinition, lastEditedColumn string) error {
+	column := findColumn(columns, lastEditedColumn)
+	if column == nil {
+		return fmt.Errorf("last edited column %q not found", lastEditedColumn)
+	}
+	column.ForceType(ColumTypeTime)
+	return nil
+}
+
+// rowTimeExtremes returns the oldest and newest time of a time field, including one emitted as epoch
+// milliseconds. It returns false when the field has no times.
+func rowTimeExtremes(field *data.Field) (oldest time.Time, newest time.Time, ok bool) {
+	for i := 0; i < field.Len(); i++ {
+		var t time.Time
+		switch value := field.At(i).(type) {
+		case *time.Time:
+			if value == nil {
+				continue
+			}
+			t = *value
+		case *int64:
+			if value == nil {
+				continue
+			}
+			t = time.Unix(0, *value*int64(time.Millisecond))
+		default:
+			return time.Time{}, time.Time{}, false
+		}
+		if !ok || t.Before(oldest) {
+			oldest = t
+		}
+		if !ok || t.After(newest) {
+			newest = t
+		}
+		ok = true
+	}
+	return oldest, newest, ok
+}
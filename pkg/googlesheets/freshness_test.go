@@ -0,0 +1,43 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastEditedColumn(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/last-edited.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("oldest and newest row times are reported", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", LastEditedColumn: "Edited", Sample: models.Sample{MaxRows: 1}}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2021, 4, 4, 0, 0, 0, 0, time.Local), meta["oldestRowTime"])
+		assert.Equal(t, time.Date(2021, 4, 24, 6, 0, 0, 0, time.Local), meta["newestRowTime"], "every row counts, not only the sampled ones")
+	})
+
+	t.Run("epoch milliseconds are supported", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", LastEditedColumn: "Edited", TimeAsEpochMillis: true}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.True(t, time.Date(2021, 4, 24, 6, 0, 0, 0, time.Local).Equal(meta["newestRowTime"].(time.Time)))
+	})
+
+	t.Run("missing columns are an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", LastEditedColumn: "Modified"}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `last edited column "Modified" not found`)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/google-sheets-datasource/pkg/models"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
@@ -16,14 +17,35 @@ type GoogleClient struct {
 	sheetsService *sheets.Service
 	driveService  *drive.Service
 	auth          *models.DatasourceSettings
+	// credentialSource describes the default credentials the client uses, empty for a key or JWT
+	credentialSource string
 }
 
 type client interface {
-	GetSpreadsheet(spreadSheetID string, sheetRange string, includeGridData bool) (*sheets.Spreadsheet, error)
+	GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error)
+	GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error)
+	GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error)
+	GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error)
+	BatchUpdate(ctx context.Context, spreadSheetID string, request *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, error)
+	GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error)
+	GetVersion(ctx context.Context, spreadSheetID string) (int64, error)
 }
 
 // NewGoogleClient creates a new client and initializes a sheet service and a drive service
 func NewGoogleClient(ctx context.Context, auth *models.DatasourceSettings) (*GoogleClient, error) {
+	if auth.UseDefaultCredentials {
+		sheetsService, driveService, source, err := newDefaultCredentialsServices(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		return &GoogleClient{
+			sheetsService:    sheetsService,
+			driveService:     driveService,
+			auth:             auth,
+			credentialSource: source,
+		}, nil
+	}
+
 	sheetsService, err := createSheetsService(ctx, auth)
 	if err != nil {
 		return nil, err
@@ -43,8 +65,8 @@ func NewGoogleClient(ctx context.Context, auth *models.DatasourceSettings) (*Goo
 
 // TestClient checks that the client can connect to required services
 func (gc *GoogleClient) TestClient() error {
-	// When using JWT, check the drive API
-	if gc.auth.AuthType == "jwt" {
+	// When using JWT or default credentials, check the drive API
+	if gc.auth.AuthType == "jwt" || gc.auth.UseDefaultCredentials {
 		q := gc.driveService.Files.List().Q("mimeType='application/vnd.google-apps.spreadsheet'")
 		_, err := q.Do()
 		if err != nil {
@@ -56,17 +78,73 @@ func (gc *GoogleClient) TestClient() error {
 	return nil
 }
 
-// GetSpreadsheet gets a google spreadsheet struct by id and range
-func (gc *GoogleClient) GetSpreadsheet(spreadSheetID string, sheetRange string, includeGridData bool) (*sheets.Spreadsheet, error) {
+// GetSpreadsheet gets a google spreadsheet struct by id and range. A non-empty field mask limits the returned fields.
+// The request is aborted when ctx is cancelled.
+func (gc *GoogleClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
 	req := gc.sheetsService.Spreadsheets.Get(spreadSheetID)
 	if len(sheetRange) > 0 {
 		req = req.Ranges(sheetRange)
 	}
-	return req.IncludeGridData(true).Do()
+	if len(fields) > 0 {
+		req = req.Fields(googleapi.Field(fields))
+	}
+	return req.Context(ctx).IncludeGridData(includeGridData).Do()
+}
+
+// GetSpreadsheetRanges gets a google spreadsheet struct with the grid data of several ranges in one request.
+func (gc *GoogleClient) GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error) {
+	req := gc.sheetsService.Spreadsheets.Get(spreadSheetID).Ranges(sheetRanges...)
+	if len(fields) > 0 {
+		req = req.Fields(googleapi.Field(fields))
+	}
+	return req.Context(ctx).IncludeGridData(true).Do()
+}
+
+// GetRowCount gets the number of rows of the sheet a range is on, or of the first sheet for an empty range.
+// It only reads the grid properties of the sheet, not any cell values.
+func (gc *GoogleClient) GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error) {
+	req := gc.sheetsService.Spreadsheets.Get(spreadSheetID).Fields("sheets.properties.gridProperties.rowCount")
+	if len(sheetRange) > 0 {
+		req = req.Ranges(sheetRange)
+	}
+	result, err := req.Context(ctx).Do()
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Sheets) == 0 || result.Sheets[0].Properties == nil || result.Sheets[0].Properties.GridProperties == nil {
+		return 0, fmt.Errorf("spreadsheet %q has no sheet for range %q", spreadSheetID, sheetRange)
+	}
+	return int(result.Sheets[0].Properties.GridProperties.RowCount), nil
+}
+
+// BatchUpdate applies a batchUpdate request to a spreadsheet.
+func (gc *GoogleClient) BatchUpdate(ctx context.Context, spreadSheetID string, request *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	return gc.sheetsService.Spreadsheets.BatchUpdate(spreadSheetID, request).Context(ctx).Do()
+}
+
+// GetLatestRevisionID gets the ID of the head revision of a spreadsheet from the Drive API.
+func (gc *GoogleClient) GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error) {
+	file, err := gc.driveService.Files.Get(spreadSheetID).Fields("headRevisionId").Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the head revision: %w", err)
+	}
+	if file.HeadRevisionId == "" {
+		return "", fmt.Errorf("spreadsheet %q has no head revision", spreadSheetID)
+	}
+	return file.HeadRevisionId, nil
+}
+
+// GetVersion gets the version of a spreadsheet from the Drive API, which goes up with every change to it.
+func (gc *GoogleClient) GetVersion(ctx context.Context, spreadSheetID string) (int64, error) {
+	file, err := gc.driveService.Files.Get(spreadSheetID).Fields("version").Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the version: %w", err)
+	}
+	return file.Version, nil
 }
 
 // GetSpreadsheetFiles lists all files with spreadsheet mimetype that the client has access to.
-func (gc *GoogleClient) GetSpreadsheetFiles() ([]*drive.File, error) {
+func (gc *GoogleClient) GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error) {
 	fs := []*drive.File{}
 	pageToken := ""
 	for {
@@ -74,7 +152,7 @@ func (gc *GoogleClient) GetSpreadsheetFiles() ([]*drive.File, error) {
 		if pageToken != "" {
 			q = q.PageToken(pageToken)
 		}
-		r, err := q.Do()
+		r, err := q.Context(ctx).Do()
 		if err != nil {
 			return nil, fmt.Errorf("failed to list spreadsheet files, page token %q: %w", pageToken, err)
 		}
@@ -94,23 +172,36 @@ func createSheetsService(ctx context.Context, auth *models.DatasourceSettings) (
 		return nil, fmt.Errorf("missing AuthType setting")
 	}
 
+	opts := []option.ClientOption{}
+	if len(auth.APIEndpoint) > 0 {
+		opts = append(opts, option.WithEndpoint(auth.APIEndpoint))
+	}
+
+	transport, err := newTransport(auth)
+	if err != nil {
+		return nil, err
+	}
+
 	if auth.AuthType == "key" {
 		if len(auth.APIKey) == 0 {
 			return nil, fmt.Errorf("missing API Key")
 		}
-		return sheets.NewService(ctx, option.WithAPIKey(auth.APIKey))
+		return sheets.NewService(ctx, append(opts, apiKeyOption(auth.APIKey, transport))...)
 	}
 
 	if auth.AuthType == "jwt" {
-		jwtConfig, err := google.JWTConfigFromJSON([]byte(auth.JWT),
-			// Only need readonly access to spreadsheets (and drive?)
-			"https://www.googleapis.com/auth/spreadsheets.readonly")
+		// Only need readonly access to spreadsheets unless writes are allowed
+		scope := sheets.SpreadsheetsReadonlyScope
+		if auth.AllowWrites {
+			scope = sheets.SpreadsheetsScope
+		}
+		jwtConfig, err := google.JWTConfigFromJSON([]byte(auth.JWT), scope)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing JWT file: %w", err)
 		}
 
-		client := jwtConfig.Client(ctx)
-		return sheets.NewService(ctx, option.WithHTTPClient(client))
+		client := jwtConfig.Client(proxyContext(ctx, transport))
+		return sheets.NewService(ctx, append(opts, option.WithHTTPClient(client))...)
 	}
 
 	return nil, fmt.Errorf("invalid Auth Type: %s", auth.AuthType)
@@ -121,11 +212,16 @@ func createDriveService(ctx context.Context, auth *models.DatasourceSettings) (*
 		return nil, fmt.Errorf("missing AuthType setting")
 	}
 
+	transport, err := newTransport(auth)
+	if err != nil {
+		return nil, err
+	}
+
 	if auth.AuthType == "key" {
 		if len(auth.APIKey) == 0 {
 			return nil, fmt.Errorf("missing API Key")
 		}
-		return drive.NewService(ctx, option.WithAPIKey(auth.APIKey))
+		return drive.NewService(ctx, apiKeyOption(auth.APIKey, transport))
 	}
 
 	if auth.AuthType == "jwt" {
@@ -134,7 +230,7 @@ func createDriveService(ctx context.Context, auth *models.DatasourceSettings) (*
 			return nil, fmt.Errorf("error parsing JWT file: %w", err)
 		}
 
-		client := jwtConfig.Client(ctx)
+		client := jwtConfig.Client(proxyContext(ctx, transport))
 		return drive.NewService(ctx, option.WithHTTPClient(client))
 	}
 	return nil, fmt.Errorf("invalid Auth Type: %s", auth.AuthType)
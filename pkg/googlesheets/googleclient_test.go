@@ -0,0 +1,286 @@
+package googlesheets
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+func TestGoogleClient(t *testing.T) {
+	t.Run("API endpoint override is applied to the sheets service", func(t *testing.T) {
+		var requestedPath, requestedKey, requestedFields string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			requestedKey = r.URL.Query().Get("key")
+			requestedFields = r.URL.Query().Get("fields")
+			_, _ = w.Write([]byte(`{"spreadsheetId": "someid"}`))
+		}))
+		defer server.Close()
+
+		client, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{
+			AuthType:    "key",
+			APIKey:      "secret",
+			APIEndpoint: server.URL + "/",
+		})
+		require.NoError(t, err)
+
+		spreadsheet, err := client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.NoError(t, err)
+		assert.Equal(t, "someid", spreadsheet.SpreadsheetId)
+		assert.Equal(t, "/v4/spreadsheets/someid", requestedPath)
+		assert.Equal(t, "secret", requestedKey)
+		assert.Equal(t, "", requestedFields)
+
+		_, err = client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "spreadsheetId")
+		require.NoError(t, err)
+		assert.Equal(t, "spreadsheetId", requestedFields)
+	})
+	t.Run("requests are sent through the configured proxy", func(t *testing.T) {
+		var requestedHost, proxyAuthorization, requestedKey string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedHost = r.URL.Host
+			proxyAuthorization = r.Header.Get("Proxy-Authorization")
+			requestedKey = r.URL.Query().Get("key")
+			_, _ = w.Write([]byte(`{"spreadsheetId": "someid"}`))
+		}))
+		defer proxy.Close()
+
+		client, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{
+			AuthType:      "key",
+			APIKey:        "secret",
+			APIEndpoint:   "http://sheets.example.com/",
+			ProxyURL:      proxy.URL,
+			ProxyUsername: "grafana",
+			ProxyPassword: "password",
+		})
+		require.NoError(t, err)
+
+		_, err = client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.NoError(t, err)
+		assert.Equal(t, "sheets.example.com", requestedHost)
+		assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("grafana:password")), proxyAuthorization)
+		assert.Equal(t, "secret", requestedKey)
+	})
+
+	t.Run("transport uses the configured proxy", func(t *testing.T) {
+		transport, err := newTransport(&models.DatasourceSettings{ProxyURL: "http://proxy.internal:3128"})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, "https://sheets.googleapis.com/v4/spreadsheets/someid", nil)
+		require.NoError(t, err)
+		proxyURL, err := transport.Proxy(request)
+		require.NoError(t, err)
+		assert.Equal(t, "http://proxy.internal:3128", proxyURL.String())
+
+		transport, err = newTransport(&models.DatasourceSettings{})
+		require.NoError(t, err)
+		assert.Nil(t, transport)
+
+		_, err = newTransport(&models.DatasourceSettings{ProxyURL: "not a url"})
+		assert.Error(t, err)
+	})
+
+	t.Run("client certificate is presented to servers that require mutual TLS", func(t *testing.T) {
+		var peerCertificates int
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peerCertificates = len(r.TLS.PeerCertificates)
+			_, _ = w.Write([]byte(`{"spreadsheetId": "someid"}`))
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+		defer server.Close()
+
+		certPEM, keyPEM := generateClientCertificate(t)
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		settings := &models.DatasourceSettings{
+			AuthType:      "key",
+			APIKey:        "secret",
+			APIEndpoint:   server.URL + "/",
+			TLSClientCert: string(certPEM),
+			TLSClientKey:  string(keyPEM),
+			TLSCACert:     string(caPEM),
+		}
+
+		transport, err := newTransport(settings)
+		require.NoError(t, err)
+		require.NotNil(t, transport.TLSClientConfig)
+		assert.Len(t, transport.TLSClientConfig.Certificates, 1)
+
+		client, err := NewGoogleClient(context.Background(), settings)
+		require.NoError(t, err)
+		_, err = client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, peerCertificates)
+	})
+
+	t.Run("invalid client certificates are rejected", func(t *testing.T) {
+		certPEM, _ := generateClientCertificate(t)
+		_, otherKeyPEM := generateClientCertificate(t)
+
+		err := ValidateTLSSettings(&models.DatasourceSettings{TLSClientCert: string(certPEM), TLSClientKey: string(otherKeyPEM)})
+		assert.Error(t, err)
+		err = ValidateTLSSettings(&models.DatasourceSettings{TLSClientCert: string(certPEM)})
+		assert.Error(t, err)
+		err = ValidateTLSSettings(&models.DatasourceSettings{TLSCACert: "not a certificate"})
+		assert.Error(t, err)
+		assert.NoError(t, ValidateTLSSettings(&models.DatasourceSettings{}))
+	})
+
+	t.Run("quota headers of the response are reported", func(t *testing.T) {
+		withQuota := true
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if withQuota {
+				w.Header().Set("X-RateLimit-Limit", "300")
+				w.Header().Set("X-RateLimit-Remaining", "297")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"spreadsheetId": "someid", "properties": {}}`))
+		}))
+		defer server.Close()
+
+		client, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{
+			AuthType:    "key",
+			APIKey:      "secret",
+			APIEndpoint: server.URL + "/",
+		})
+		require.NoError(t, err)
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		_, meta, err := gsd.getSheetData(context.Background(), client, &models.QueryModel{Spreadsheet: "someid", Range: "A1:B"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"x-ratelimit-limit": "300", "x-ratelimit-remaining": "297"}, meta["quota"])
+
+		withQuota = false
+		_, meta, err = gsd.getSheetData(context.Background(), client, &models.QueryModel{Spreadsheet: "someid", Range: "A1:B"})
+		require.NoError(t, err)
+		assert.NotContains(t, meta, "quota")
+	})
+
+	t.Run("cancelling the query context aborts the request", func(t *testing.T) {
+		requested := make(chan struct{})
+		aborted := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requested)
+			select {
+			case <-r.Context().Done():
+				close(aborted)
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer server.Close()
+
+		client, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{
+			AuthType:    "key",
+			APIKey:      "secret",
+			APIEndpoint: server.URL + "/",
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-requested
+			cancel()
+		}()
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := &models.QueryModel{Spreadsheet: "someid", Range: "A1:B", CacheDurationSeconds: 10, ErrorCacheDuration: time.Minute}
+		_, _, err = gsd.getSheetData(ctx, client, qm)
+		assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+		assert.Equal(t, 0, gsd.Cache.ItemCount(), "cancellations are not cached")
+
+		select {
+		case <-aborted:
+		case <-time.After(time.Second):
+			t.Fatal("the request was not aborted")
+		}
+	})
+}
+
+func TestDefaultCredentials(t *testing.T) {
+	find := findDefaultCredentials
+	defer func() { findDefaultCredentials = find }()
+
+	var requestedScopes []string
+	findDefaultCredentials = func(ctx context.Context, scopes ...string) (*google.Credentials, error) {
+		requestedScopes = scopes
+		return &google.Credentials{TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "workload-token"})}, nil
+	}
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"spreadsheetId": "someid"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{
+		UseDefaultCredentials: true,
+		APIEndpoint:           server.URL + "/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "metadata server", client.credentialSource)
+	assert.Contains(t, requestedScopes, "https://www.googleapis.com/auth/spreadsheets.readonly")
+
+	_, err = client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer workload-token", authorization)
+
+	t.Run("key files are described by their type", func(t *testing.T) {
+		require.NoError(t, os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/var/secrets/key.json"))
+		defer os.Unsetenv("GOOGLE_APPLICATION_CREDENTIALS")
+		source := credentialSource(&google.Credentials{JSON: []byte(`{"type": "service_account"}`)})
+		assert.Equal(t, "service account key from GOOGLE_APPLICATION_CREDENTIALS (/var/secrets/key.json)", source)
+	})
+
+	t.Run("missing default credentials fail the client", func(t *testing.T) {
+		findDefaultCredentials = func(ctx context.Context, scopes ...string) (*google.Credentials, error) {
+			return nil, errors.New("could not find default credentials")
+		}
+		_, err := NewGoogleClient(context.Background(), &models.DatasourceSettings{UseDefaultCredentials: true})
+		assert.Error(t, err)
+	})
+}
+
+func generateClientCertificate(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grafana"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
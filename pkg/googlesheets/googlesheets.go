@@ -2,7 +2,10 @@ package googlesheets
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"context"
@@ -16,53 +19,514 @@ import (
 	"google.golang.org/api/sheets/v4"
 )
 
+// consistencyRetries bounds the extra fetches of a query waiting for consistency.
+const consistencyRetries = 3
+
+// defaultConsistencyRetryDelay is the delay between refetches when waiting for consistency.
+const defaultConsistencyRetryDelay = 250 * time.Millisecond
+
+// DefaultMaxConcurrentTransforms is the number of sheets the datasource fetches and caches at the same time.
+const DefaultMaxConcurrentTransforms = 8
+
 // GoogleSheets provides an interface to the Google Sheets API.
 type GoogleSheets struct {
 	Cache *cache.Cache
+	// MaxConcurrentTransforms limits how many sheets are fetched and cached at once, 0 for no limit
+	MaxConcurrentTransforms int
+	// Metrics is optional, nothing is recorded without it
+	Metrics *Metrics
+	// ConsistencyRetryDelay is the delay between refetches of queries waiting for consistency, 0 for the default
+	ConsistencyRetryDelay time.Duration
+
+	transformSlots     chan struct{}
+	transformSlotsOnce sync.Once
+
+	rateLimiters   map[int64]*rateLimiter
+	rateLimitersMu sync.Mutex
+	retryBudgets   map[int64]*retryBudget
+	retryBudgetsMu sync.Mutex
+	streams        map[string]*streamQuery
+	streamsMu      sync.Mutex
+}
+
+// prepareQuery composes and normalizes the range of a query and validates it.
+func prepareQuery(qm *models.QueryModel) error {
+	if qm.SheetName != "" || qm.StartCell != "" || qm.EndCell != "" {
+		sheetRange, err := composeRange(qm)
+		if err != nil {
+			return err
+		}
+		qm.Range = sheetRange
+	}
+	if qm.NormalizeRange && qm.Range != "" && !isDateTemplateRange(qm.Range) {
+		sheetRange, err := normalizeRange(qm.Range)
+		if err != nil {
+			return err
+		}
+		qm.Range = sheetRange
+	}
+	if qm.NormalizeRange && len(qm.SheetRanges) > 0 {
+		sheetRanges := make(map[string]string, len(qm.SheetRanges))
+		for tab, cells := range qm.SheetRanges {
+			if cells != "" {
+				// With the tab in front the cells can't be taken for a named range
+				prefix := quoteSheetName(tab) + "!"
+				sheetRange, err := normalizeRange(prefix + cells)
+				if err != nil {
+					return err
+				}
+				cells = strings.TrimPrefix(sheetRange, prefix)
+			}
+			sheetRanges[tab] = cells
+		}
+		qm.SheetRanges = sheetRanges
+	}
+	return validateQuery(qm)
+}
+
+// newClient creates a Google API client wrapped with the metrics, rate limiter and retry budget of the datasource.
+// The unwrapped client is returned too, for what it knows about its credentials.
+func (gs *GoogleSheets) newClient(ctx context.Context, config *models.DatasourceSettings) (client, *GoogleClient, error) {
+	googleClient, err := NewGoogleClient(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create Google API client: %w", err)
+	}
+	// Retries go through the rate limiter like any other request
+	return gs.withRetryBudget(gs.withRateLimit(gs.withMetrics(googleClient), config), config), googleClient, nil
 }
 
 // Query queries a spreadsheet and returns a corresponding data frame.
 func (gs *GoogleSheets) Query(ctx context.Context, refID string, qm *models.QueryModel, config *models.DatasourceSettings, timeRange backend.TimeRange) (dr backend.DataResponse) {
-	client, err := NewGoogleClient(ctx, config)
-	if err != nil {
-		dr.Error = fmt.Errorf("unable to create Google API client: %w", err)
+	gs.Metrics.observeQuery(qm.QueryType)
+	if err := prepareQuery(qm); err != nil {
+		dr.Error = err
 		return
 	}
 
-	// This result may be cached
-	data, meta, err := gs.getSheetData(client, qm)
-	if err != nil {
-		dr.Error = err
+	if qm.QueryType == models.QueryTypeValidateCredentials {
+		// Credentials are checked before creating a client, since that fails for invalid ones
+		dr.Frames = append(dr.Frames, credentialsFrame(ctx, refID, qm, config))
+		return
+	}
+
+	if qm.PublishedCSVURL != "" {
+		// Published sheets are public, so no client is needed
+		qm.CacheJitter = float64(config.CacheJitterPercent) / 100
+		frame, err := gs.queryPublishedCSV(ctx, refID, qm, config)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames, dr.Error = finishFrames([]*data.Frame{frame}, qm, timeRange)
 		return
 	}
 
-	frame, err := gs.transformSheetToDataFrame(data, meta, refID, qm)
+	client, googleClient, err := gs.newClient(ctx, config)
 	if err != nil {
 		dr.Error = err
 		return
 	}
-	if frame == nil {
+	qm.ErrorCacheDuration = time.Duration(config.ErrorCacheDurationSeconds) * time.Second
+	qm.CacheJitter = float64(config.CacheJitterPercent) / 100
+	qm.CacheSizeTiers = config.CacheSizeTiers
+
+	switch qm.QueryType {
+	case models.QueryTypeTestAPI:
+		dr.Frames = append(dr.Frames, testCapabilities(ctx, client, googleClient.credentialSource, refID, qm, config.AllowWrites))
+		return
+	case models.QueryTypeConditionalFormats:
+		frame, err := gs.getConditionalFormats(ctx, client, refID, qm)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
+		return
+	case models.QueryTypeListSheets:
+		frame, err := gs.getSheetList(ctx, client, refID, qm)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
+		return
+	case models.QueryTypeListCharts:
+		frame, err := gs.getCharts(ctx, client, refID, qm)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
+		return
+	case models.QueryTypeListNamedRanges:
+		frame, err := gs.getNamedRanges(ctx, client, refID, qm)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
+		return
+	case models.QueryTypeCellTypes:
+		dr.Frames, dr.Error = gs.getCellTypes(ctx, client, refID, qm)
+		return
+	case models.QueryTypeSnapshot:
+		frame, err := gs.snapshot(ctx, client, refID, qm, config, timeRange)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
+		return
+	case models.QueryTypeBatchUpdate:
+		frame, err := batchUpdate(ctx, client, refID, qm, config)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		dr.Frames = append(dr.Frames, frame)
 		return
 	}
-	if qm.UseTimeFilter {
-		timeIndex := findTimeField(frame)
-		if timeIndex >= 0 {
-			frame, dr.Error = frame.FilterRowsByField(timeIndex, func(i interface{}) (bool, error) {
-				val, ok := i.(*time.Time)
-				if !ok {
-					return false, fmt.Errorf("invalid time column: %s", spew.Sdump(i))
-				}
-				if val == nil || val.Before(timeRange.From) || val.After(timeRange.To) {
-					return false, nil
-				}
-				return true, nil
-			})
+
+	var frames []*data.Frame
+	if isDateTemplateRange(qm.Range) {
+		frames, err = gs.queryDateTabs(ctx, client, refID, qm, config.MaxConcurrentFetches)
+	} else if len(qm.SheetRanges) > 0 {
+		frames, err = gs.querySheetRanges(ctx, client, refID, qm)
+	} else {
+		var frame *data.Frame
+		frame, err = gs.querySheet(ctx, client, refID, qm)
+		if isOutOfBoundsError(err) {
+			frame, err = noticeFrame(refID, fmt.Sprintf("Range %q is outside of the sheet: %v", qm.Range, err)), nil
+		}
+		if frame != nil {
+			frames = []*data.Frame{frame}
+		}
+	}
+	if err != nil {
+		dr.Error = err
+		return
+	}
+	dr.Frames, dr.Error = finishFrames(frames, qm, timeRange)
+	if dr.Error == nil && qm.Stream && config.UID != "" {
+		path, err := gs.registerStream(config.UID, refID, qm)
+		if err != nil {
+			dr.Error = err
+			return
+		}
+		for _, frame := range dr.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Channel = streamChannel(config, path)
 		}
 	}
-	dr.Frames = append(dr.Frames, frame)
 	return
 }
 
+// finishFrames applies the steps that need the whole query to the frames of a sheet query.
+func finishFrames(frames []*data.Frame, qm *models.QueryModel, timeRange backend.TimeRange) ([]*data.Frame, error) {
+	finished := make([]*data.Frame, 0, len(frames))
+	for _, frame := range frames {
+		if qm.UseTimeFilter {
+			var err error
+			frame, err = filterByTimeRange(frame, timeRange)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if qm.Filter != "" {
+			var err error
+			frame, err = applyFilter(frame, qm.Filter, timeRange, qm.CaseInsensitiveColumns)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if qm.ErrorOnEmpty && frame.Rows() == 0 {
+			return nil, fmt.Errorf("no data rows in range %q: %w", qm.Range, ErrEmptyRange)
+		}
+		if qm.AlertMode {
+			if err := validateAlertFrame(frame); err != nil {
+				return nil, err
+			}
+		}
+		if qm.SingleRowAsScalars {
+			finished = append(finished, scalarFrames(frame)...)
+			continue
+		}
+		finished = append(finished, frame)
+	}
+	return finished, nil
+}
+
+// filterByTimeRange keeps the rows whose first time field is within the time range.
+func filterByTimeRange(frame *data.Frame, timeRange backend.TimeRange) (*data.Frame, error) {
+	timeIndex := findTimeField(frame)
+	if timeIndex < 0 {
+		return frame, nil
+	}
+	return filterFieldByTimeRange(frame, timeIndex, timeRange)
+}
+
+// filterFieldByTimeRange keeps the rows whose value of the time field at timeIndex is within the time range.
+// The field may be nullable or not, see concreteFields.
+func filterFieldByTimeRange(frame *data.Frame, timeIndex int, timeRange backend.TimeRange) (*data.Frame, error) {
+	return frame.FilterRowsByField(timeIndex, func(i interface{}) (bool, error) {
+		var val *time.Time
+		switch value := i.(type) {
+		case *time.Time:
+			val = value
+		case time.Time:
+			val = &value
+		default:
+			return false, fmt.Errorf("invalid time column: %s", spew.Sdump(i))
+		}
+		if val == nil || val.Before(timeRange.From) || val.After(timeRange.To) {
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// querySheet gets the sheet data and transforms it into a data frame.
+func (gs *GoogleSheets) querySheet(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	if qm.SchemaRange != "" {
+		var err error
+		qm, err = gs.withSchema(ctx, client, qm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metadataRange := ""
+	if qm.RangeFromMetadataKey != "" {
+		var err error
+		metadataRange, err = gs.resolveMetadataRange(ctx, client, qm)
+		if err != nil {
+			return nil, err
+		}
+		withRange := *qm
+		withRange.Range = metadataRange
+		qm = &withRange
+	}
+
+	resolvedNamedRange := ""
+	if qm.Range != "" && !strings.Contains(qm.Range, "!") && hasSheetName(qm.Range) {
+		// A bare name is either a sheet or a named range, map named ranges to their owning sheet
+		resolved, found, err := gs.resolveNamedRange(ctx, client, qm)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			resolvedNamedRange = resolved
+			withRange := *qm
+			withRange.Range = resolved
+			qm = &withRange
+		}
+	}
+
+	resolvedSheet := ""
+	if qm.Range != "" && !hasSheetName(qm.Range) {
+		// Without a sheet name the API would pick the first sheet, even if it is hidden
+		var err error
+		resolvedSheet, err = gs.resolveFirstVisibleSheet(ctx, client, qm)
+		if err != nil {
+			return nil, err
+		}
+		withSheet := *qm
+		withSheet.Range = quoteSheetName(resolvedSheet) + "!" + qm.Range
+		qm = &withSheet
+	}
+
+	expandedFrom := ""
+	if qm.AutoExpandRange {
+		expanded, err := expandRange(ctx, client, qm)
+		if err != nil {
+			return nil, err
+		}
+		if expanded != qm.Range {
+			expandedFrom = qm.Range
+			withRange := *qm
+			withRange.Range = expanded
+			qm = &withRange
+		}
+	}
+
+	// Queue instead of allocating and transforming many large sheets at the same time
+	release, err := gs.acquireTransformSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// This result may be cached
+	spreadsheet, meta, err := gs.getSheetData(ctx, client, qm)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedSheet != "" {
+		meta["resolvedSheet"] = resolvedSheet
+	}
+	if resolvedNamedRange != "" {
+		meta["resolvedNamedRange"] = resolvedNamedRange
+	}
+	if metadataRange != "" {
+		meta["metadataRange"] = metadataRange
+	}
+
+	sheet := spreadsheet.Sheets[0]
+	headerWarning := ""
+	if qm.HeaderRange != "" {
+		sheet, headerWarning, err = gs.withHeaderRange(ctx, client, qm, sheet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frame, err := gs.transformSheetToDataFrame(sheet, meta, refID, withSpreadsheetLocation(qm, spreadsheet))
+	if err != nil {
+		return nil, err
+	}
+	if headerWarning != "" {
+		meta["warnings"] = append(meta["warnings"].([]string), headerWarning)
+	}
+	if spreadsheet.Properties != nil {
+		applyDefaultFormat(frame, spreadsheet.Properties.DefaultFormat)
+	}
+	if expandedFrom != "" {
+		warning := fmt.Sprintf("Data extends past range %q, expanded it to %q", expandedFrom, qm.Range)
+		meta["warnings"] = append([]string{warning}, meta["warnings"].([]string)...)
+	}
+
+	if qm.JoinRange != "" {
+		// The joined range is queried with a slot of its own
+		release()
+		return gs.joinRange(ctx, client, refID, qm, frame)
+	}
+	return frame, nil
+}
+
+// validateQuery checks the query options that can be rejected before fetching any data.
+func validateQuery(qm *models.QueryModel) error {
+	if _, err := compileRegexFilters(qm.RegexFilter); err != nil {
+		return err
+	}
+	if qm.Filter != "" {
+		if _, err := parseTimeFilter(qm.Filter); err != nil {
+			return err
+		}
+	}
+	if qm.PublishedCSVURL != "" {
+		if err := validatePublishedCSVURL(qm.PublishedCSVURL); err != nil {
+			return err
+		}
+	}
+	if isDateTemplateRange(qm.Range) {
+		if _, err := expandDateTabs(qm); err != nil {
+			return err
+		}
+	}
+	if qm.QueryType == models.QueryTypeBatchUpdate {
+		if _, err := parseBatchUpdateRequest(qm); err != nil {
+			return err
+		}
+	}
+	if qm.QueryType == models.QueryTypeSnapshot {
+		if err := validateSnapshot(qm); err != nil {
+			return err
+		}
+	}
+	if err := validateColumnTypes(qm.ColumnTypes); err != nil {
+		return err
+	}
+	if err := validateDuplicateSuffixFormat(qm.DuplicateSuffixFormat); err != nil {
+		return err
+	}
+	if err := validateSample(qm.Sample); err != nil {
+		return err
+	}
+	if err := validatePivot(qm); err != nil {
+		return err
+	}
+	if err := validateGroupBy(qm); err != nil {
+		return err
+	}
+	if err := validateDerivePeriods(qm); err != nil {
+		return err
+	}
+	if err := validateCurrencyNormalization(qm.NormalizeCurrency); err != nil {
+		return err
+	}
+	if err := validateJoin(qm); err != nil {
+		return err
+	}
+	if err := validateErrorCellPolicy(qm.ErrorCellPolicy); err != nil {
+		return err
+	}
+	if err := validateNewlineHandling(qm.NewlineHandling); err != nil {
+		return err
+	}
+	if err := validateDerivedColumns(qm.DerivedColumns); err != nil {
+		return err
+	}
+	if qm.RawValuesLimit < 0 {
+		return fmt.Errorf("raw values limit must not be negative")
+	}
+	if qm.MaxStringLength < 0 {
+		return fmt.Errorf("max string length must not be negative")
+	}
+	if err := validateColumnGlobs(qm.ColumnIncludeGlob, qm.ColumnExcludeGlob); err != nil {
+		return err
+	}
+	if len(qm.SheetRanges) > 0 && qm.Range != "" {
+		return fmt.Errorf("sheet ranges and range can not be used together")
+	}
+	if qm.Stream && (len(qm.SheetRanges) > 0 || isDateTemplateRange(qm.Range)) {
+		return fmt.Errorf("streams read a single range, not sheet ranges or date tabs")
+	}
+	if qm.Stream && (qm.ReverseRows || qm.Sample.EveryNth > 1 || qm.Sample.MaxRows > 0) {
+		return fmt.Errorf("streams send the appended rows in sheet order, reverse rows and sampling can not be used together with them")
+	}
+	if qm.Stream && (qm.Transposed || qm.GroupByColumn != "" || qm.PivotColumn != "" || qm.TimeSeriesWide) {
+		return fmt.Errorf("streams send sheet rows, which transposed, grouped, pivoted or wide time series frames don't have")
+	}
+	if qm.IncludeRowRange && qm.Transposed {
+		return fmt.Errorf("row ranges can not be included for a transposed range")
+	}
+	if qm.StreamIntervalSeconds < 0 {
+		return fmt.Errorf("stream interval must not be negative")
+	}
+	if qm.RangeFromMetadataKey != "" && (qm.Range != "" || len(qm.SheetRanges) > 0) {
+		return fmt.Errorf("range from metadata key and range can not be used together")
+	}
+	if qm.TimeSeriesWide && (qm.GroupByColumn != "" || qm.PivotColumn != "") {
+		return fmt.Errorf("wide time series and group by or pivot columns can not be used together")
+	}
+	if qm.HeaderRange != "" && qm.UseFrozenRowsAsHeader {
+		return fmt.Errorf("header range and frozen rows as header can not be used together")
+	}
+	if err := validateSplitColumns(qm.SplitColumns); err != nil {
+		return err
+	}
+	if (qm.RowHeaderColumn || qm.Matrix) && qm.LabelColumn != "" {
+		return fmt.Errorf("row header column and label column can not be used together")
+	}
+	if qm.Matrix && qm.PivotColumn != "" {
+		return fmt.Errorf("matrix and pivot column can not be used together")
+	}
+	if qm.AlertMode && qm.TimeAsEpochMillis {
+		return fmt.Errorf("alert mode needs time values, not epoch milliseconds")
+	}
+	if (qm.UseTimeFilter || qm.Filter != "") && qm.TimeAsEpochMillis {
+		return fmt.Errorf("the time filter needs time values, not epoch milliseconds")
+	}
+	if qm.TimeOffset != "" {
+		if _, err := time.ParseDuration(qm.TimeOffset); err != nil {
+			return fmt.Errorf("invalid time offset %q: %w", qm.TimeOffset, err)
+		}
+	}
+	return nil
+}
+
 // GetSpreadsheets gets spreadsheets from the Google API.
 func (gs *GoogleSheets) GetSpreadsheets(ctx context.Context, config *models.DatasourceSettings) (map[string]string, error) {
 	client, err := NewGoogleClient(ctx, config)
@@ -70,7 +534,7 @@ func (gs *GoogleSheets) GetSpreadsheets(ctx context.Context, config *models.Data
 		return nil, fmt.Errorf("failed to create Google API client: %w", err)
 	}
 
-	files, err := client.GetSpreadsheetFiles()
+	files, err := client.GetSpreadsheetFiles(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -83,98 +547,584 @@ func (gs *GoogleSheets) GetSpreadsheets(ctx context.Context, config *models.Data
 	return fileNames, nil
 }
 
-// getSheetData gets grid data corresponding to a spreadsheet.
-func (gs *GoogleSheets) getSheetData(client client, qm *models.QueryModel) (*sheets.GridData, map[string]interface{}, error) {
-	cacheKey := qm.Spreadsheet + qm.Range
+// getSheetData gets a spreadsheet with the grid data of the queried sheet and the spreadsheet properties.
+func (gs *GoogleSheets) getSheetData(ctx context.Context, client client, qm *models.QueryModel) (*sheets.Spreadsheet, map[string]interface{}, error) {
+	fields := gridDataFields(qm)
+	sheetRanges := sheetRangeList(qm.SheetRanges)
+	cacheKey := qm.Spreadsheet + qm.Range + strings.Join(sheetRanges, ",") + fields
+	if qm.RevisionID != "" {
+		// Data cached for a revision stays valid after the spreadsheet changes
+		cacheKey += "@" + qm.RevisionID
+	}
+	previousRowCount := -1
+	rowCountKey := "rowCount:" + cacheKey
+	if count, found := gs.Cache.Get(rowCountKey); found && qm.WaitForConsistency {
+		// The row count of the last fetch is kept even when the data is not cached
+		previousRowCount = count.(int)
+	}
 	if item, expires, found := gs.Cache.GetWithExpiration(cacheKey); found && qm.CacheDurationSeconds > 0 {
-		return item.(*sheets.GridData), map[string]interface{}{
-			"hit":     true,
-			"expires": expires.Unix(),
-		}, nil
+		cached := item.(*cachedSpreadsheet).spreadsheet
+		switch {
+		case qm.WaitForConsistency:
+			// Use the cached data as the baseline the fresh data should differ from
+			if previousRowCount < 0 {
+				previousRowCount = gridRowCount(cached)
+			}
+		case qm.RefreshOnGrowth && sheetHasGrown(ctx, client, qm, cached.Sheets[0]):
+			gs.Cache.Delete(cacheKey)
+		default:
+			gs.Metrics.observeCacheLookup(true)
+			return cached, map[string]interface{}{
+				"hit":             true,
+				"expires":         expires.Unix(),
+				"cacheTtlSeconds": item.(*cachedSpreadsheet).ttl.Seconds(),
+			}, nil
+		}
+	}
+
+	// Identical queries fail fast while the spreadsheet is unavailable instead of each hitting the API
+	errorCacheKey := "error:" + cacheKey
+	if cachedErr, found := gs.Cache.Get(errorCacheKey); found {
+		return nil, nil, cachedErr.(error)
 	}
 
-	result, err := client.GetSpreadsheet(qm.Spreadsheet, qm.Range, true)
+	if qm.RevisionID != "" {
+		if err := checkRevision(ctx, client, qm); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	gs.Metrics.observeCacheLookup(false)
+	var result *sheets.Spreadsheet
+	var err error
+	if len(sheetRanges) > 0 {
+		result, err = client.GetSpreadsheetRanges(ctx, qm.Spreadsheet, sheetRanges, fields)
+	} else {
+		result, err = client.GetSpreadsheet(ctx, qm.Spreadsheet, qm.Range, true, fields)
+	}
 	if err != nil {
+		if qm.ErrorCacheDuration > 0 && isTransientError(err) {
+			gs.Cache.Set(errorCacheKey, err, qm.ErrorCacheDuration)
+		}
 		return nil, nil, err
 	}
 
-	if result.Properties.TimeZone != "" {
-		loc, err := time.LoadLocation(result.Properties.TimeZone)
+	if previousRowCount >= 0 {
+		result, err = waitForRowCountChange(ctx, client, qm, result, previousRowCount, gs.consistencyRetryDelay())
 		if err != nil {
-			backend.Logger.Warn("could not load timezone from spreadsheet: %w", err)
-		} else {
-			time.Local = loc
+			return nil, nil, err
 		}
 	}
+	if qm.RevisionID != "" {
+		// The spreadsheet may have changed while it was fetched, and the data would no longer match the revision
+		if err := checkRevision(ctx, client, qm); err != nil {
+			return nil, nil, err
+		}
+	}
+	if qm.WaitForConsistency {
+		gs.Cache.Set(rowCountKey, gridRowCount(result), cache.DefaultExpiration)
+	}
 
-	data := result.Sheets[0].Data[0]
+	meta := map[string]interface{}{"hit": false}
+	if quota := quotaInfo(result.Header); quota != nil {
+		meta["quota"] = quota
+	}
 	if qm.CacheDurationSeconds > 0 {
-		gs.Cache.Set(cacheKey, data, time.Duration(qm.CacheDurationSeconds)*time.Second)
+		// Spread out the expiry of identical queries so they don't all refetch at once
+		ttl := jitterDuration(sizeCacheDuration(time.Duration(qm.CacheDurationSeconds)*time.Second, countCells(result), qm.CacheSizeTiers), qm.CacheJitter)
+		gs.Cache.Set(cacheKey, &cachedSpreadsheet{spreadsheet: result, ttl: ttl}, ttl)
+		meta["cacheTtlSeconds"] = ttl.Seconds()
 	}
 
-	return data, map[string]interface{}{"hit": false}, nil
+	return result, meta, nil
+}
+
+// cachedSpreadsheet is the spreadsheet cached by getSheetData, with the TTL it was cached for.
+type cachedSpreadsheet struct {
+	spreadsheet *sheets.Spreadsheet
+	ttl         time.Duration
+}
+
+// sheetHasGrown checks whether the sheet has more rows than when its grid data was cached. Only the sheet
+// properties are read, so rows filled in below the data of a sheet that did not grow are not noticed.
+func sheetHasGrown(ctx context.Context, client client, qm *models.QueryModel, cached *sheets.Sheet) bool {
+	if cached.Properties == nil || cached.Properties.GridProperties == nil {
+		return false
+	}
+	rowCount, err := client.GetRowCount(ctx, qm.Spreadsheet, qm.Range)
+	if err != nil {
+		backend.Logger.Warn("Could not check row count, using cached data", "error", err)
+		return false
+	}
+	return int64(rowCount) > cached.Properties.GridProperties.RowCount
+}
+
+func (gs *GoogleSheets) consistencyRetryDelay() time.Duration {
+	if gs.ConsistencyRetryDelay > 0 {
+		return gs.ConsistencyRetryDelay
+	}
+	return defaultConsistencyRetryDelay
+}
+
+// gridRowCount is the number of rows in the grid data of the first sheet of a spreadsheet.
+func gridRowCount(spreadsheet *sheets.Spreadsheet) int {
+	if len(spreadsheet.Sheets) == 0 || len(spreadsheet.Sheets[0].Data) == 0 {
+		return 0
+	}
+	return len(spreadsheet.Sheets[0].Data[0].RowData)
+}
+
+// waitForRowCountChange refetches the spreadsheet at most consistencyRetries times until
+// its row count differs from previousRowCount, e.g. after a write that has not propagated yet.
+func waitForRowCountChange(ctx context.Context, client client, qm *models.QueryModel, result *sheets.Spreadsheet, previousRowCount int, delay time.Duration) (*sheets.Spreadsheet, error) {
+	for attempt := 0; attempt < consistencyRetries; attempt++ {
+		if gridRowCount(result) != previousRowCount {
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		var err error
+		result, err = client.GetSpreadsheet(ctx, qm.Spreadsheet, qm.Range, true, gridDataFields(qm))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backend.Logger.Warn("Row count did not change while waiting for consistency", "spreadsheet", qm.Spreadsheet, "range", qm.Range)
+	return result, nil
+}
+
+func (gs *GoogleSheets) transformSheetToDataFrame(sheet *sheets.Sheet, meta map[string]interface{}, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	return gs.transformSheet(sheet, meta, refID, qm, nil)
+}
+
+// sourceCells are the columns and data rows of the sheet a frame was built from.
+type sourceCells struct {
+	columns []*ColumnDefinition
+	rows    []*sheets.RowData
 }
 
-func (gs *GoogleSheets) transformSheetToDataFrame(sheet *sheets.GridData, meta map[string]interface{}, refID string, qm *models.QueryModel) (*data.Frame, error) {
-	columns, start := getColumnDefinitions(sheet.RowData)
+// transformSheet builds the frame of a sheet, keeping the columns and rows it was built from in source
+// when it is not nil.
+func (gs *GoogleSheets) transformSheet(sheet *sheets.Sheet, meta map[string]interface{}, refID string, qm *models.QueryModel, source *sourceCells) (*data.Frame, error) {
+	if len(sheet.Data) == 0 {
+		return nil, fmt.Errorf("no grid data found in range %q", qm.Range)
+	}
+	grid := sheet.Data[0]
+
+	if qm.IncludeRawValues {
+		rawValues, truncated := getRawValues(grid, qm.RawValuesLimit)
+		meta["rawValues"] = rawValues
+		if truncated {
+			meta["rawValuesTruncated"] = true
+		}
+	}
+
+	if qm.AnchorCellValue != "" {
+		var err error
+		grid, err = sliceFromAnchor(grid, qm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.Transposed {
+		grid = transposeGrid(grid)
+	}
+
+	headerRowCount := 1
+	if qm.UseFrozenRowsAsHeader {
+		headerRowCount = getFrozenHeaderRowCount(sheet, grid)
+	}
+
+	columns, start := getColumnHeaders(grid.RowData, headerRowCount, qm.DuplicateSuffixFormat)
+	if qm.CaseInsensitiveColumns {
+		var err error
+		qm, err = resolveColumnNames(qm, columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rows := grid.RowData[start:]
+	if qm.RespectHiddenRows {
+		rows = dropHiddenRows(grid, start)
+	}
 	warnings := []string{}
 
+	// Keep track of the sheet row number of each row, since rows may be dropped
+	rowNumbers := make(map[*sheets.RowData]int, len(grid.RowData))
+	for i, row := range grid.RowData {
+		rowNumbers[row] = int(grid.StartRow) + i + 1
+	}
+	rowErrors := []rowError{}
+	addRowError := func(row *sheets.RowData, reason string) {
+		if qm.CollectRowErrors {
+			rowErrors = append(rowErrors, rowError{Row: rowNumbers[row], Reason: reason})
+		}
+	}
+
+	if qm.TrimStrings {
+		rows = mapCells(rows, rowNumbers, trimCell)
+	}
+
+	if len(qm.NullTokens) > 0 {
+		rows = mapCells(rows, rowNumbers, nullTokenCell(qm.NullTokens))
+	}
+
+	if replacer, ok := newlineReplacers[qm.NewlineHandling]; ok {
+		rows = mapCells(rows, rowNumbers, newlineCell(replacer))
+	}
+
+	if len(qm.NumericStrip) > 0 {
+		var err error
+		rows, err = stripNumericColumns(rows, columns, rowNumbers, qm.NumericStrip)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.SkipEmptyRows {
+		var skipped int
+		rows, skipped = dropEmptyRows(rows)
+		meta["skippedEmptyRows"] = skipped
+	}
+
+	if len(qm.RegexFilter) > 0 {
+		var err error
+		rows, err = filterRowsByRegex(rows, columns, qm.RegexFilter, addRowError)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.ColumnIncludeGlob != "" || qm.ColumnExcludeGlob != "" {
+		var err error
+		columns, err = selectColumns(columns, qm.ColumnIncludeGlob, qm.ColumnExcludeGlob)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := applyErrorCellPolicy(rows, columns, rowNumbers, qm.ErrorCellPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	percentColumns := map[*ColumnDefinition]bool{}
+	if qm.TextPercentages {
+		var percentWarnings []string
+		rows, percentColumns, percentWarnings = convertTextPercentages(rows, columns, rowNumbers, qm.PercentAsFraction)
+		warnings = append(warnings, percentWarnings...)
+	}
+
+	if qm.IncludeColumnFormulas {
+		meta["columnFormulas"] = getColumnFormulas(rows, columns)
+	}
+
+	sample := rows
+	if qm.TypeDetectionSampleSize > 0 && qm.TypeDetectionSampleSize < len(rows) {
+		// Values outside the sample that don't fit the detected type become null with a warning
+		sample = rows[:qm.TypeDetectionSampleSize]
+	}
+	checkColumnTypes(columns, sample)
+
+	if len(qm.ColumnTypes) > 0 {
+		if err := applyColumnTypes(columns, qm.ColumnTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.TimeColumn != "" {
+		if err := applyTimeColumn(columns, qm.TimeColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.LastEditedColumn != "" {
+		if err := applyLastEditedColumn(columns, qm.LastEditedColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.LabelColumn != "" {
+		if err := applyLabelColumn(columns, qm.LabelColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.RowHeaderColumn || qm.Matrix {
+		if err := applyRowHeaderColumn(columns); err != nil {
+			return nil, err
+		}
+	}
+
+	epochUnits, err := applyEpochColumns(columns, qm.EpochSecondsColumns, qm.EpochMillisColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	bigNumberColumns, err := applyBigNumberColumns(columns, qm.BigNumberAsString)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(qm.CoercionFallback) > 0 {
+		if err := applyCoercionFallbackTypes(columns, qm.CoercionFallback); err != nil {
+			return nil, err
+		}
+	}
+
+	wideTimeColumn, otherTimeColumns := "", map[string]bool(nil)
+	if qm.TimeSeriesWide {
+		if wideTimeColumn, otherTimeColumns, err = timeSeriesColumns(columns, qm.TimeColumn); err != nil {
+			return nil, err
+		}
+	}
+
 	converters := make([]data.FieldConverter, len(columns))
 	for i, column := range columns {
 		fc, ok := converterMap[column.GetType()]
 		if !ok {
 			return nil, fmt.Errorf("unknown column type: %s", column.GetType())
 		}
+		if column.GetType() == ColumTypeTime {
+			fc = newTimeConverter(qm.Location)
+			if qm.TimeAsEpochMillis {
+				fc = newEpochMillisConverter(qm.Location)
+			}
+		}
+		if column.GetType() == ColumTypeNumber && column.GetUnit() == timeOfDayUnit {
+			fc = timeOfDayConverter
+		}
+		if unit, ok := epochUnits[column]; ok {
+			fc = newEpochConverter(unit, qm.TimeAsEpochMillis)
+		}
+		if bigNumberColumns[column] {
+			fc = bigNumberConverter
+		}
 		converters[i] = fc
 	}
 
-	inputConverter, err := data.NewFrameInputConverter(converters, len(sheet.RowData)-start)
+	inputConverter, err := data.NewFrameInputConverter(converters, len(rows))
 	if err != nil {
 		return nil, err
 	}
+	if source != nil {
+		source.columns, source.rows = columns, rows
+	}
 	frame := inputConverter.Frame
 	frame.RefID = refID
 	frame.Name = refID // TODO: should set the name from metadata
+	if qm.FrameName != "" {
+		frame.Name = qm.FrameName
+	}
+
+	fallbacks, err := coercionFallbackValues(frame, columns, qm.CoercionFallback)
+	if err != nil {
+		return nil, err
+	}
+	fallbackCounts := map[int]int{}
 
+	schema := make([]schemaColumn, len(columns))
 	for i, column := range columns {
+		schema[i] = schemaColumn{
+			Name:       column.Header,
+			Type:       column.GetType(),
+			MixedTypes: column.HasMixedTypes() && column.forcedType == "",
+		}
+
 		field := frame.Fields[i]
 		field.Name = column.Header
 		field.Config = &data.FieldConfig{
 			DisplayName: column.Header,
 			Unit:        column.GetUnit(),
+			Decimals:    column.GetDecimals(),
+		}
+		if percentColumns[column] && qm.PercentAsFraction {
+			field.Config.Unit = "percentunit"
+		}
+		if column.GetType() == ColumTypeTime && qm.TimeAsEpochMillis && field.Config.Unit == "" {
+			// Let panels know the numbers are timestamps
+			field.Config.Unit = "dateTimeAsIso"
 		}
-		if column.HasMixedTypes() {
+		if column.HasMixedTypes() && column.forcedType == "" {
 			warning := fmt.Sprintf("Multiple data types found in column %q. Using string data type", column.Header)
 			warnings = append(warnings, warning)
 			backend.Logger.Warn(warning)
 		}
 
-		if column.HasMixedUnits() {
+		if column.HasMixedUnits() && !(qm.NormalizeCurrency.Target != "" && isCurrencyColumn(column)) {
 			warning := fmt.Sprintf("Multiple units found in column %q. Formatted value will be used", column.Header)
 			warnings = append(warnings, warning)
 			backend.Logger.Warn(warning)
 		}
 	}
 
-	for rowIndex := start; rowIndex < len(sheet.RowData); rowIndex++ {
-		for columnIndex, cellData := range sheet.RowData[rowIndex].Values {
-			if columnIndex >= len(columns) {
+	for rowIndex, row := range rows {
+		for fieldIndex, column := range columns {
+			if column.ColumnIndex >= len(row.Values) {
 				continue
 			}
+			cellData := row.Values[column.ColumnIndex]
 
-			// Skip any empty values
-			if cellData.FormattedValue == "" {
+			// Skip any empty values. A string cell with an effective value, e.g. a formula
+			// returning "", is an empty string rather than null.
+			if cellData == nil || (cellData.FormattedValue == "" && (cellData.EffectiveValue == nil || column.GetType() != ColumTypeString)) {
 				continue
 			}
 
-			err := inputConverter.Set(columnIndex, rowIndex-start, cellData)
+			err := inputConverter.Set(fieldIndex, rowIndex, cellData)
 			if err != nil {
+				addRowError(row, fmt.Sprintf("column %q: %s", column.Header, err.Error()))
+				if fallback, ok := fallbacks[fieldIndex]; ok {
+					frame.Fields[fieldIndex].Set(rowIndex, fallback)
+					fallbackCounts[fieldIndex]++
+					continue
+				}
 				warnings = append(warnings, err.Error())
 			}
 		}
 	}
+	for fieldIndex, column := range columns {
+		if count := fallbackCounts[fieldIndex]; count > 0 {
+			warnings = append(warnings, fmt.Sprintf("Used the fallback value for %d cells in column %q", count, column.Header))
+		}
+	}
+
+	if qm.NormalizeCurrency.Target != "" {
+		warnings = append(warnings, normalizeCurrencyFields(frame, rows, columns, qm.NormalizeCurrency)...)
+	}
+
+	warnings = append(warnings, unsafeIntegerWarnings(frame, columns)...)
+
+	if len(qm.Scale) > 0 {
+		warnings = append(warnings, scaleNumberFields(frame, columns, qm.Scale)...)
+	}
+
+	if qm.TimeOffset != "" {
+		offset, err := time.ParseDuration(qm.TimeOffset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time offset %q: %w", qm.TimeOffset, err)
+		}
+		shiftTimeFields(frame, columns, offset)
+	}
+
+	if qm.LastEditedColumn != "" {
+		// Computed over every row of the sheet, before rows are sampled
+		if oldest, newest, ok := rowTimeExtremes(frame.Fields[frameFieldIndex(frame, qm.LastEditedColumn)]); ok {
+			meta["oldestRowTime"] = oldest
+			meta["newestRowTime"] = newest
+		}
+	}
+
+	if len(qm.SplitColumns) > 0 {
+		if err := splitFields(frame, qm.SplitColumns); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qm.DerivedColumns) > 0 {
+		if err := addDerivedColumns(frame, qm.DerivedColumns); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qm.DerivePeriods) > 0 {
+		if err := addPeriodFields(frame, qm.TimeColumn, qm.DerivePeriods, qm.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.MaxStringLength > 0 {
+		meta["truncatedCells"] = truncateStringFields(frame, qm.MaxStringLength)
+	}
+
+	if qm.ExtractImages {
+		frame.Fields = append(frame.Fields, getImageFields(rows, columns)...)
+	}
+
+	if qm.IncludeRowRange {
+		sheetTitle := sheetNameOf(qm.Range)
+		if sheet.Properties != nil && sheet.Properties.Title != "" {
+			sheetTitle = sheet.Properties.Title
+		}
+		frame.Fields = append(frame.Fields, getRowRangeField(rows, rowNumbers, sheetTitle))
+	}
+
+	if qm.PivotColumn != "" {
+		pivoted, pivotWarnings, err := pivotFrame(frame, qm.PivotIndexColumns, qm.PivotColumn, qm.PivotValueColumn)
+		if err != nil {
+			return nil, err
+		}
+		frame = pivoted
+		warnings = append(warnings, pivotWarnings...)
+	}
+
+	if qm.GroupByColumn != "" {
+		frame, err = groupFrame(frame, qm.GroupByColumn, qm.Aggregations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.TimeSeriesWide {
+		var wideWarnings []string
+		frame, wideWarnings, err = wideTimeSeriesFrame(frame, wideTimeColumn, otherTimeColumns)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, wideWarnings...)
+	}
+
+	if len(qm.ConstantFields) > 0 {
+		if err := addConstantFields(frame, qm.ConstantFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qm.ColumnOrder) > 0 {
+		if err := reorderFields(frame, qm.ColumnOrder); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.ReverseRows {
+		// Reverse before sampling so that MaxRows with Sample.FromStart keeps the newest rows
+		frame = reverseFrame(frame)
+	}
+
+	if len(qm.ForwardFillColumns) > 0 {
+		if err := forwardFillFields(frame, qm.ForwardFillColumns); err != nil {
+			return nil, err
+		}
+	}
+
+	if qm.Sample.EveryNth > 1 || qm.Sample.MaxRows > 0 {
+		indexes := sampleRowIndexes(frame.Rows(), qm.Sample)
+		if len(indexes) < frame.Rows() {
+			frame = sampleFrame(frame, indexes)
+			meta["sampled"] = true
+		}
+	}
+
+	if qm.NullableFields != nil && !*qm.NullableFields {
+		concreteFields(frame, qm.EmptyValue)
+	}
+
+	if qm.ComputeFieldRanges {
+		setFieldRanges(frame)
+	}
 
 	meta["warnings"] = warnings
+	meta["schema"] = schema
+	if qm.CollectRowErrors {
+		meta["rowErrors"] = rowErrors
+	}
 	meta["spreadsheetId"] = qm.Spreadsheet
 	meta["range"] = qm.Range
 	frame.Meta = &data.FrameMeta{Custom: meta}
@@ -182,21 +1132,58 @@ func (gs *GoogleSheets) transformSheetToDataFrame(sheet *sheets.GridData, meta m
 	return frame, nil
 }
 
-// timeConverter handles sheets TIME column types.
-var timeConverter = data.FieldConverter{
-	OutputFieldType: data.FieldTypeNullableTime,
-	Converter: func(i interface{}) (interface{}, error) {
-		var t *time.Time
-		cellData, ok := i.(*sheets.CellData)
-		if !ok {
-			return t, fmt.Errorf("expected type *sheets.CellData, but got %T", i)
-		}
-		parsedTime, err := dateparse.ParseLocal(cellData.FormattedValue)
-		if err != nil {
-			return t, fmt.Errorf("Error while parsing date '%v'", cellData.FormattedValue)
-		}
-		return &parsedTime, nil
-	},
+// schemaColumn describes the detected type of a column.
+type schemaColumn struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type"`
+	// MixedTypes is set when the column fell back to string because it has multiple types
+	MixedTypes bool `json:"mixedTypes"`
+}
+
+// rowError describes why a row was dropped or could not be fully converted.
+type rowError struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// newTimeConverter handles sheets TIME column types, parsing the formatted values in the time zone loc.
+// A nil loc is the local time zone.
+func newTimeConverter(loc *time.Location) data.FieldConverter {
+	if loc == nil {
+		loc = time.Local
+	}
+	return data.FieldConverter{
+		OutputFieldType: data.FieldTypeNullableTime,
+		Converter: func(i interface{}) (interface{}, error) {
+			var t *time.Time
+			cellData, ok := i.(*sheets.CellData)
+			if !ok {
+				return t, fmt.Errorf("expected type *sheets.CellData, but got %T", i)
+			}
+			parsedTime, err := dateparse.ParseIn(cellData.FormattedValue, loc)
+			if err != nil {
+				return t, fmt.Errorf("Error while parsing date '%v'", cellData.FormattedValue)
+			}
+			return &parsedTime, nil
+		},
+	}
+}
+
+// newEpochMillisConverter handles sheets TIME column types that are emitted as epoch milliseconds.
+func newEpochMillisConverter(loc *time.Location) data.FieldConverter {
+	timeConverter := newTimeConverter(loc)
+	return data.FieldConverter{
+		OutputFieldType: data.FieldTypeNullableInt64,
+		Converter: func(i interface{}) (interface{}, error) {
+			var ms *int64
+			t, err := timeConverter.Converter(i)
+			if err != nil {
+				return ms, err
+			}
+			millis := t.(*time.Time).UnixNano() / int64(time.Millisecond)
+			return &millis, nil
+		},
+	}
 }
 
 // stringConverter handles sheets STRING column types.
@@ -212,7 +1199,7 @@ var stringConverter = data.FieldConverter{
 	},
 }
 
-// numberConverter handles sheets STRING column types.
+// numberConverter handles sheets NUMBER column types.
 var numberConverter = data.FieldConverter{
 	OutputFieldType: data.FieldTypeNullableFloat64,
 	Converter: func(i interface{}) (interface{}, error) {
@@ -220,19 +1207,38 @@ var numberConverter = data.FieldConverter{
 		if !ok {
 			return nil, fmt.Errorf("expected type *sheets.CellData, but got %T", i)
 		}
+		if cellData.EffectiveValue == nil || cellData.EffectiveValue.NumberValue == nil {
+			return nil, fmt.Errorf("expected numeric value but got '%v'", cellData.FormattedValue)
+		}
 		return cellData.EffectiveValue.NumberValue, nil
 	},
 }
 
+// timeOfDayConverter handles sheets NUMBER column types of TIME formatted cells, converting the fraction of a day to seconds since midnight.
+var timeOfDayConverter = data.FieldConverter{
+	OutputFieldType: data.FieldTypeNullableFloat64,
+	Converter: func(i interface{}) (interface{}, error) {
+		value, err := numberConverter.Converter(i)
+		if err != nil {
+			return nil, err
+		}
+		// Round to milliseconds, the precision of Sheets times
+		seconds := math.Round(*value.(*float64)*24*60*60*1000) / 1000
+		return &seconds, nil
+	},
+}
+
 // converterMap is a map sheets.ColumnType to fieldConverter and
 // is used to create a data.FrameInputConverter for a returned sheet.
 var converterMap = map[ColumnType]data.FieldConverter{
-	"TIME":   timeConverter,
+	"TIME":   newTimeConverter(nil),
 	"STRING": stringConverter,
 	"NUMBER": numberConverter,
 }
 
-func getUniqueColumnName(formattedName string, columnIndex int, columns map[string]bool) string {
+// getUniqueColumnName returns a name for the column that is not in columns yet.
+// Duplicates are suffixed using suffixFormat, or with a bare counter when it is empty.
+func getUniqueColumnName(formattedName string, columnIndex int, columns map[string]bool, suffixFormat string) string {
 	name := formattedName
 	if name == "" {
 		name = fmt.Sprintf("Field %d", columnIndex+1)
@@ -240,9 +1246,13 @@ func getUniqueColumnName(formattedName string, columnIndex int, columns map[stri
 
 	nameExist := true
 	counter := 1
+	if suffixFormat != "" {
+		// The first duplicate is the second occurrence of the name
+		counter = 2
+	}
 	for nameExist {
 		if _, exist := columns[name]; exist {
-			name = fmt.Sprintf("%s%d", formattedName, counter)
+			name = formatDuplicateName(formattedName, counter, suffixFormat)
 			counter++
 		} else {
 			nameExist = false
@@ -252,35 +1262,117 @@ func getUniqueColumnName(formattedName string, columnIndex int, columns map[stri
 	return name
 }
 
-func getColumnDefinitions(rows []*sheets.RowData) ([]*ColumnDefinition, int) {
+// formatDuplicateName applies a suffix format such as "{name} ({n})" to a duplicate name.
+func formatDuplicateName(name string, n int, suffixFormat string) string {
+	if suffixFormat == "" {
+		return fmt.Sprintf("%s%d", name, n)
+	}
+	return strings.NewReplacer("{name}", name, "{n}", strconv.Itoa(n)).Replace(suffixFormat)
+}
+
+func validateDuplicateSuffixFormat(suffixFormat string) error {
+	if suffixFormat != "" && (!strings.Contains(suffixFormat, "{name}") || !strings.Contains(suffixFormat, "{n}")) {
+		return fmt.Errorf("duplicate suffix format %q must contain {name} and {n}", suffixFormat)
+	}
+	return nil
+}
+
+// applyLabelColumn forces the label column to be a string and every other column to be numeric, unless its
+// type was already set, e.g. by ColumnTypes or TimeColumn.
+func applyLabelColumn(columns []*ColumnDefinition, labelColumn string) error {
+	found := false
+	for _, column := range columns {
+		if column.Header == labelColumn {
+			column.ForceType(ColumTypeString)
+			found = true
+		} else if column.forcedType == "" {
+			column.ForceType(ColumTypeNumber)
+		}
+	}
+	if !found {
+		return fmt.Errorf("label column %q not found", labelColumn)
+	}
+	return nil
+}
+
+// applyRowHeaderColumn uses the first column as the row identifiers of a matrix and every other column as a numeric series.
+func applyRowHeaderColumn(columns []*ColumnDefinition) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("row header column requires at least one column")
+	}
+
+	// The top left cell of a matrix is usually empty
+	if columns[0].Header == getUniqueColumnName("", 0, map[string]bool{}, "") {
+		columns[0].Header = "Row"
+	}
+	return applyLabelColumn(columns, columns[0].Header)
+}
+
+// getFrozenHeaderRowCount returns the number of frozen rows within the grid data.
+// Sheets without frozen rows fall back to a single header row.
+func getFrozenHeaderRowCount(sheet *sheets.Sheet, grid *sheets.GridData) int {
+	if sheet.Properties == nil || sheet.Properties.GridProperties == nil || sheet.Properties.GridProperties.FrozenRowCount == 0 {
+		return 1
+	}
+
+	count := int(sheet.Properties.GridProperties.FrozenRowCount - grid.StartRow)
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// getColumnHeaders creates the column definitions from the header rows and
+// returns them together with the index of the first data row. Multiple header
+// rows are joined into a single name per column.
+func getColumnHeaders(rows []*sheets.RowData, headerRowCount int, suffixFormat string) ([]*ColumnDefinition, int) {
 	columns := []*ColumnDefinition{}
 	columnMap := map[string]bool{}
-	headerRow := rows[0].Values
 
-	start := 0
-	if len(rows) > 1 {
-		start = 1
-		for columnIndex, headerCell := range headerRow {
-			name := getUniqueColumnName(strings.TrimSpace(headerCell.FormattedValue), columnIndex, columnMap)
+	if len(rows) == 0 {
+		// The API leaves out the row data of an empty range
+		return columns, 0
+	}
+	if headerRowCount == 0 || len(rows) <= headerRowCount {
+		for columnIndex := range rows[0].Values {
+			name := getUniqueColumnName("", columnIndex, columnMap, suffixFormat)
 			columnMap[name] = true
 			columns = append(columns, NewColumnDefinition(name, columnIndex))
 		}
-	} else {
-		for columnIndex := range headerRow {
-			name := getUniqueColumnName("", columnIndex, columnMap)
-			columnMap[name] = true
-			columns = append(columns, NewColumnDefinition(name, columnIndex))
+		return columns, 0
+	}
+
+	columnCount := 0
+	for _, row := range rows[:headerRowCount] {
+		if len(row.Values) > columnCount {
+			columnCount = len(row.Values)
 		}
 	}
 
-	// Check the types for each column
-	for rowIndex := start; rowIndex < len(rows); rowIndex++ {
-		for _, column := range columns {
-			if column.ColumnIndex < len(rows[rowIndex].Values) {
-				column.CheckCell(rows[rowIndex].Values[column.ColumnIndex])
+	for columnIndex := 0; columnIndex < columnCount; columnIndex++ {
+		parts := []string{}
+		for _, row := range rows[:headerRowCount] {
+			if columnIndex < len(row.Values) && row.Values[columnIndex] != nil {
+				if part := strings.TrimSpace(row.Values[columnIndex].FormattedValue); part != "" {
+					parts = append(parts, part)
+				}
 			}
 		}
+		name := getUniqueColumnName(strings.Join(parts, " "), columnIndex, columnMap, suffixFormat)
+		columnMap[name] = true
+		columns = append(columns, NewColumnDefinition(name, columnIndex))
 	}
 
-	return columns, start
+	return columns, headerRowCount
+}
+
+// checkColumnTypes checks the types for each column
+func checkColumnTypes(columns []*ColumnDefinition, rows []*sheets.RowData) {
+	for _, row := range rows {
+		for _, column := range columns {
+			if column.ColumnIndex < len(row.Values) {
+				column.CheckCell(row.Values[column.ColumnIndex])
+			}
+		}
+	}
 }
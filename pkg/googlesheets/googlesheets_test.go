@@ -1,25 +1,137 @@
 package googlesheets
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/sheets/v4"
 )
 
 type fakeClient struct {
+	rowCount            int
+	getSpreadsheetCalls int
+	// responses are returned in order, repeating the last one, instead of the mixed-data sheet
+	responses         []*sheets.Spreadsheet
+	getSpreadsheetErr error
+	filesErr          error
+	// sheetsByRange returns the spreadsheet for a requested range, or a range error if it is missing
+	sheetsByRange       map[string]*sheets.Spreadsheet
+	requestedRanges     []string
+	requestedFields     []string
+	batchUpdateRequests []*sheets.BatchUpdateSpreadsheetRequest
+	batchUpdateErr      error
+	latestRevisionID    string
+	// latestRevisionIDs are returned in order, repeating the last one, instead of latestRevisionID
+	latestRevisionIDs []string
+	// versions are returned in order, repeating the last one
+	versions []int64
+	// rowCounts are returned in order, repeating the last one, instead of rowCount
+	rowCounts []int
 }
 
-func (f *fakeClient) GetSpreadsheet(spreadSheetID string, sheetRange string, includeGridData bool) (*sheets.Spreadsheet, error) {
+func (f *fakeClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
+	f.getSpreadsheetCalls++
+	f.requestedRanges = append(f.requestedRanges, sheetRange)
+	f.requestedFields = append(f.requestedFields, fields)
+	if f.getSpreadsheetErr != nil {
+		return nil, f.getSpreadsheetErr
+	}
+	if f.sheetsByRange != nil {
+		spreadsheet, ok := f.sheetsByRange[sheetRange]
+		if !ok {
+			return nil, &googleapi.Error{Code: http.StatusBadRequest, Message: "Unable to parse range: " + sheetRange}
+		}
+		return spreadsheet, nil
+	}
+	if len(f.responses) > 0 {
+		index := f.getSpreadsheetCalls - 1
+		if index >= len(f.responses) {
+			index = len(f.responses) - 1
+		}
+		return f.responses[index], nil
+	}
 	return loadTestSheet("./testdata/mixed-data.json")
 }
 
+func (f *fakeClient) GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error) {
+	return f.GetSpreadsheet(ctx, spreadSheetID, strings.Join(sheetRanges, ","), true, fields)
+}
+
+func (f *fakeClient) GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error) {
+	if len(f.rowCounts) > 0 {
+		count := f.rowCounts[0]
+		if len(f.rowCounts) > 1 {
+			f.rowCounts = f.rowCounts[1:]
+		}
+		return count, nil
+	}
+	return f.rowCount, nil
+}
+
+func (f *fakeClient) GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error) {
+	if f.filesErr != nil {
+		return nil, f.filesErr
+	}
+	return []*drive.File{{Id: "someid", Name: "Some spreadsheet"}}, nil
+}
+
+func (f *fakeClient) BatchUpdate(ctx context.Context, spreadSheetID string, request *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	f.batchUpdateRequests = append(f.batchUpdateRequests, request)
+	if f.batchUpdateErr != nil {
+		return nil, f.batchUpdateErr
+	}
+	replies := make([]*sheets.Response, len(request.Requests))
+	for i, r := range request.Requests {
+		if r.AddSheet != nil {
+			properties := *r.AddSheet.Properties
+			properties.SheetId = 42
+			replies[i] = &sheets.Response{AddSheet: &sheets.AddSheetResponse{Properties: &properties}}
+		}
+	}
+	return &sheets.BatchUpdateSpreadsheetResponse{
+		SpreadsheetId: spreadSheetID,
+		Replies:       replies,
+	}, nil
+}
+
+func (f *fakeClient) GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error) {
+	if len(f.latestRevisionIDs) > 0 {
+		revisionID := f.latestRevisionIDs[0]
+		if len(f.latestRevisionIDs) > 1 {
+			f.latestRevisionIDs = f.latestRevisionIDs[1:]
+		}
+		return revisionID, nil
+	}
+	return f.latestRevisionID, nil
+}
+
+func (f *fakeClient) GetVersion(ctx context.Context, spreadSheetID string) (int64, error) {
+	if len(f.versions) == 0 {
+		return 0, nil
+	}
+	version := f.versions[0]
+	if len(f.versions) > 1 {
+		f.versions = f.versions[1:]
+	}
+	return version, nil
+}
+
 func loadTestSheet(path string) (*sheets.Spreadsheet, error) {
 	jsonBody, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -34,19 +146,55 @@ func loadTestSheet(path string) (*sheets.Spreadsheet, error) {
 	return &sheet, nil
 }
 
+func fieldByName(t *testing.T, frame *data.Frame, name string) *data.Field {
+	for _, field := range frame.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	require.Failf(t, "field not found", "no field named %q", name)
+	return nil
+}
+
 func TestGooglesheets(t *testing.T) {
 	t.Run("getUniqueColumnName", func(t *testing.T) {
 		t.Run("name is appended with number if not unique", func(t *testing.T) {
 			columns := map[string]bool{"header": true, "name": true}
-			name := getUniqueColumnName("header", 1, columns)
+			name := getUniqueColumnName("header", 1, columns, "")
 			assert.Equal(t, "header1", name)
 		})
 
 		t.Run("name becomes Field + column index if header row is empty", func(t *testing.T) {
 			columns := map[string]bool{}
-			name := getUniqueColumnName("", 3, columns)
+			name := getUniqueColumnName("", 3, columns, "")
 			assert.Equal(t, "Field 4", name)
 		})
+
+		t.Run("duplicate suffix format is applied", func(t *testing.T) {
+			for suffixFormat, expected := range map[string][]string{
+				"":             {"header", "header1", "header2"},
+				"{name} ({n})": {"header", "header (2)", "header (3)"},
+				"{name}_{n}":   {"header", "header_2", "header_3"},
+			} {
+				columns := map[string]bool{}
+				names := []string{}
+				for i := 0; i < 3; i++ {
+					name := getUniqueColumnName("header", i, columns, suffixFormat)
+					columns[name] = true
+					names = append(names, name)
+				}
+				assert.Equal(t, expected, names, suffixFormat)
+			}
+		})
+
+		t.Run("suffixed names stay unique", func(t *testing.T) {
+			columns := map[string]bool{"header": true, "header_2": true}
+			assert.Equal(t, "header_3", getUniqueColumnName("header", 2, columns, "{name}_{n}"))
+		})
+
+		t.Run("suffix format without a counter is rejected", func(t *testing.T) {
+			assert.Error(t, validateQuery(&models.QueryModel{DuplicateSuffixFormat: "{name}_copy"}))
+		})
 	})
 
 	t.Run("getSheetData", func(t *testing.T) {
@@ -59,13 +207,13 @@ func TestGooglesheets(t *testing.T) {
 			qm := models.QueryModel{Range: "A1:O", Spreadsheet: "someid", CacheDurationSeconds: 10}
 			require.Equal(t, 0, gsd.Cache.ItemCount())
 
-			_, meta, err := gsd.getSheetData(client, &qm)
+			_, meta, err := gsd.getSheetData(context.Background(), client, &qm)
 			require.NoError(t, err)
 
 			assert.False(t, meta["hit"].(bool))
 			assert.Equal(t, 1, gsd.Cache.ItemCount())
 
-			_, meta, err = gsd.getSheetData(client, &qm)
+			_, meta, err = gsd.getSheetData(context.Background(), client, &qm)
 			require.NoError(t, err)
 			assert.True(t, meta["hit"].(bool))
 			assert.Equal(t, 1, gsd.Cache.ItemCount())
@@ -78,12 +226,247 @@ func TestGooglesheets(t *testing.T) {
 			qm := models.QueryModel{Range: "A1:O", Spreadsheet: "someid", CacheDurationSeconds: 0}
 			require.Equal(t, 0, gsd.Cache.ItemCount())
 
-			_, meta, err := gsd.getSheetData(client, &qm)
+			_, meta, err := gsd.getSheetData(context.Background(), client, &qm)
 			require.NoError(t, err)
 
 			assert.False(t, meta["hit"].(bool))
 			assert.Equal(t, 0, gsd.Cache.ItemCount())
 		})
+
+		t.Run("cached spreadsheets get refetched when the sheet has grown", func(t *testing.T) {
+			client := &fakeClient{}
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			qm := models.QueryModel{Range: "A1:O", Spreadsheet: "someid", CacheDurationSeconds: 10, RefreshOnGrowth: true}
+
+			spreadsheet, meta, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.False(t, meta["hit"].(bool))
+			assert.Equal(t, 1, client.getSpreadsheetCalls)
+
+			rowCount := int(spreadsheet.Sheets[0].Properties.GridProperties.RowCount)
+			client.rowCount = rowCount
+			_, meta, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.True(t, meta["hit"].(bool))
+			assert.Equal(t, 1, client.getSpreadsheetCalls)
+
+			client.rowCount = rowCount + 1
+			_, meta, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.False(t, meta["hit"].(bool))
+			assert.Equal(t, 2, client.getSpreadsheetCalls)
+			assert.Equal(t, 1, gsd.Cache.ItemCount())
+		})
+
+		t.Run("waits for the row count to change when WaitForConsistency is set", func(t *testing.T) {
+			before, err := loadTestSheet("./testdata/label-series.json")
+			require.NoError(t, err)
+			after, err := loadTestSheet("./testdata/label-series.json")
+			require.NoError(t, err)
+			grid := after.Sheets[0].Data[0]
+			grid.RowData = append(grid.RowData, grid.RowData[1])
+
+			client := &fakeClient{responses: []*sheets.Spreadsheet{before, before, before, after}}
+			gsd := &GoogleSheets{
+				Cache:                 cache.New(300*time.Second, 50*time.Second),
+				ConsistencyRetryDelay: time.Millisecond,
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10}
+			_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+
+			qm.WaitForConsistency = true
+			result, meta, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.False(t, meta["hit"].(bool))
+			assert.Equal(t, 4, client.getSpreadsheetCalls)
+			assert.Equal(t, 6, len(result.Sheets[0].Data[0].RowData))
+		})
+
+		t.Run("gives up waiting for consistency after a bounded number of retries", func(t *testing.T) {
+			spreadsheet, err := loadTestSheet("./testdata/label-series.json")
+			require.NoError(t, err)
+
+			client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+			gsd := &GoogleSheets{
+				Cache:                 cache.New(300*time.Second, 50*time.Second),
+				ConsistencyRetryDelay: time.Millisecond,
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10}
+			_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+
+			qm.WaitForConsistency = true
+			result, _, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.Equal(t, 2+consistencyRetries, client.getSpreadsheetCalls)
+			assert.Equal(t, 5, len(result.Sheets[0].Data[0].RowData))
+		})
+
+		t.Run("waits for consistency without a cache duration", func(t *testing.T) {
+			before, err := loadTestSheet("./testdata/label-series.json")
+			require.NoError(t, err)
+			after, err := loadTestSheet("./testdata/label-series.json")
+			require.NoError(t, err)
+			grid := after.Sheets[0].Data[0]
+			grid.RowData = append(grid.RowData, grid.RowData[1])
+
+			client := &fakeClient{responses: []*sheets.Spreadsheet{before, before, after}}
+			gsd := &GoogleSheets{
+				Cache:                 cache.New(300*time.Second, 50*time.Second),
+				ConsistencyRetryDelay: time.Millisecond,
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", WaitForConsistency: true}
+			_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+
+			result, _, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.Equal(t, 3, client.getSpreadsheetCalls)
+			assert.Equal(t, 6, len(result.Sheets[0].Data[0].RowData))
+		})
+
+		t.Run("concurrent fetches and transforms are limited by MaxConcurrentTransforms", func(t *testing.T) {
+			client := &countingClient{fakeClient: &fakeClient{}}
+			gsd := &GoogleSheets{
+				Cache:                   cache.New(300*time.Second, 50*time.Second),
+				MaxConcurrentTransforms: 2,
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 6; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					qm := models.QueryModel{Spreadsheet: "someid", Range: fmt.Sprintf("Sheet1!A1:O%d", i+10)}
+					_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+					assert.NoError(t, err)
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, 6, client.getSpreadsheetCalls)
+			assert.Equal(t, 2, client.maxInFlight)
+		})
+
+		t.Run("the slot is held until the sheet is transformed", func(t *testing.T) {
+			gsd := &GoogleSheets{
+				Cache:                   cache.New(300*time.Second, 50*time.Second),
+				MaxConcurrentTransforms: 1,
+			}
+			release, err := gsd.acquireTransformSlot(context.Background())
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err = gsd.querySheet(ctx, &fakeClient{}, "A", &models.QueryModel{Spreadsheet: "someid"})
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			release()
+			release()
+			_, err = gsd.querySheet(context.Background(), &fakeClient{}, "A", &models.QueryModel{Spreadsheet: "someid"})
+			require.NoError(t, err)
+			assert.Len(t, gsd.transformSlots, 0, "slots are released after the transform")
+		})
+
+		t.Run("transient errors are cached briefly", func(t *testing.T) {
+			client := &fakeClient{getSpreadsheetErr: &googleapi.Error{Code: http.StatusServiceUnavailable, Message: "Backend Error"}}
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10, ErrorCacheDuration: time.Minute}
+
+			_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.Error(t, err)
+			_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.Error(t, err)
+			assert.Equal(t, 1, client.getSpreadsheetCalls)
+
+			var apiErr *googleapi.Error
+			assert.ErrorAs(t, err, &apiErr)
+		})
+
+		t.Run("permanent errors are not cached", func(t *testing.T) {
+			client := &fakeClient{getSpreadsheetErr: &googleapi.Error{Code: http.StatusNotFound, Message: "Requested entity was not found."}}
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10, ErrorCacheDuration: time.Minute}
+
+			_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.Error(t, err)
+			_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+			require.Error(t, err)
+			assert.Equal(t, 2, client.getSpreadsheetCalls)
+			assert.Equal(t, 0, gsd.Cache.ItemCount())
+		})
+
+		t.Run("cache durations vary within the jitter band", func(t *testing.T) {
+			durations := map[time.Duration]bool{}
+			for i := 0; i < 50; i++ {
+				d := jitterDuration(100*time.Second, 0.1)
+				assert.GreaterOrEqual(t, int64(d), int64(90*time.Second))
+				assert.LessOrEqual(t, int64(d), int64(110*time.Second))
+				durations[d] = true
+			}
+			assert.Greater(t, len(durations), 1)
+			assert.Equal(t, 100*time.Second, jitterDuration(100*time.Second, -0.1))
+
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 100, CacheJitter: 0.1}
+			before := time.Now()
+			_, _, err := gsd.getSheetData(context.Background(), &fakeClient{}, &qm)
+			require.NoError(t, err)
+			for _, item := range gsd.Cache.Items() {
+				ttl := time.Unix(0, item.Expiration).Sub(before)
+				assert.GreaterOrEqual(t, int64(ttl), int64(90*time.Second))
+				assert.LessOrEqual(t, int64(ttl), int64(111*time.Second))
+			}
+		})
+
+		t.Run("large sheets are cached longer", func(t *testing.T) {
+			small := &sheets.Spreadsheet{
+				Properties: &sheets.SpreadsheetProperties{},
+				Sheets: []*sheets.Sheet{{Data: []*sheets.GridData{{RowData: []*sheets.RowData{
+					{Values: []*sheets.CellData{{FormattedValue: "a"}, {FormattedValue: "b"}}},
+				}}}}},
+			}
+			large, err := loadTestSheet("./testdata/mixed-data.json")
+			require.NoError(t, err)
+			require.Greater(t, countCells(large), 10)
+
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			tiers := []models.CacheSizeTier{{MinCells: 10, Multiplier: 6}, {MinCells: 1000000, Multiplier: 60}}
+			qm := models.QueryModel{Spreadsheet: "small", CacheDurationSeconds: 10, CacheSizeTiers: tiers}
+			_, meta, err := gsd.getSheetData(context.Background(), &fakeClient{responses: []*sheets.Spreadsheet{small}}, &qm)
+			require.NoError(t, err)
+			assert.Equal(t, 10.0, meta["cacheTtlSeconds"])
+
+			qm = models.QueryModel{Spreadsheet: "large", CacheDurationSeconds: 10, CacheSizeTiers: tiers}
+			_, meta, err = gsd.getSheetData(context.Background(), &fakeClient{responses: []*sheets.Spreadsheet{large}}, &qm)
+			require.NoError(t, err)
+			assert.Equal(t, 60.0, meta["cacheTtlSeconds"])
+
+			_, meta, err = gsd.getSheetData(context.Background(), &fakeClient{responses: []*sheets.Spreadsheet{large}}, &qm)
+			require.NoError(t, err)
+			assert.True(t, meta["hit"].(bool))
+			assert.Equal(t, 60.0, meta["cacheTtlSeconds"], "the TTL is reported on cache hits too")
+
+			for key, item := range gsd.Cache.Items() {
+				ttl := time.Until(time.Unix(0, item.Expiration))
+				if strings.Contains(key, "large") {
+					assert.Greater(t, int64(ttl), int64(50*time.Second))
+				} else {
+					assert.LessOrEqual(t, int64(ttl), int64(10*time.Second))
+				}
+			}
+		})
 	})
 
 	t.Run("transformSheetToDataFrame", func(t *testing.T) {
@@ -96,7 +479,7 @@ func TestGooglesheets(t *testing.T) {
 		qm := models.QueryModel{Range: "A1:O", Spreadsheet: "someid", CacheDurationSeconds: 10}
 
 		meta := make(map[string]interface{})
-		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0].Data[0], meta, "ref1", &qm)
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
 		require.NoError(t, err)
 		require.Equal(t, "ref1", frame.Name)
 
@@ -123,6 +506,18 @@ func TestGooglesheets(t *testing.T) {
 			assert.Equal(t, "Multiple units found in column \"Mixed currencies\". Formatted value will be used", warnings[2])
 			//assert.Equal(t, "Multiple data types found in column \"MixedUnits\". Using string data type", warnings[2])
 		})
+
+		t.Run("meta schema reflects the detected types", func(t *testing.T) {
+			schema := meta["schema"].([]schemaColumn)
+			require.Len(t, schema, 16)
+			assert.Equal(t, schemaColumn{Name: "Date", Type: ColumTypeTime}, schema[0])
+			assert.Equal(t, schemaColumn{Name: "Number", Type: ColumTypeNumber}, schema[4])
+			assert.Equal(t, schemaColumn{Name: "SimpleString", Type: ColumTypeString}, schema[9])
+			assert.Equal(t, schemaColumn{Name: "MixedDataTypes", Type: ColumTypeString, MixedTypes: true}, schema[10])
+			for _, column := range schema {
+				assert.Equal(t, column.Name == "MixedDataTypes", column.MixedTypes, column.Name)
+			}
+		})
 	})
 
 	t.Run("query single cell", func(t *testing.T) {
@@ -135,7 +530,7 @@ func TestGooglesheets(t *testing.T) {
 		qm := models.QueryModel{Range: "A2", Spreadsheet: "someid", CacheDurationSeconds: 10}
 
 		meta := make(map[string]interface{})
-		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0].Data[0], meta, "ref1", &qm)
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
 		require.NoError(t, err)
 		require.Equal(t, "ref1", frame.Name)
 
@@ -157,6 +552,289 @@ func TestGooglesheets(t *testing.T) {
 		})
 	})
 
+	t.Run("frozen rows as header", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/frozen-rows.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", UseFrozenRowsAsHeader: true}
+
+		t.Run("one frozen row", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &qm)
+			require.NoError(t, err)
+			require.Equal(t, 3, len(frame.Fields))
+			assert.Equal(t, "Region", frame.Fields[0].Name)
+			assert.Equal(t, "Sales", frame.Fields[1].Name)
+			assert.Equal(t, 3, frame.Fields[1].Len())
+		})
+
+		t.Run("two frozen rows", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[1], meta, "ref1", &qm)
+			require.NoError(t, err)
+			require.Equal(t, 4, len(frame.Fields))
+			assert.Equal(t, "Region", frame.Fields[0].Name)
+			assert.Equal(t, "Sales Q1", frame.Fields[1].Name)
+			assert.Equal(t, "Sales Q2", frame.Fields[2].Name)
+			assert.Equal(t, "Costs Q1", frame.Fields[3].Name)
+			require.Equal(t, 2, frame.Fields[1].Len())
+			assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+			assert.Equal(t, 20.0, *frame.Fields[2].At(0).(*float64))
+		})
+
+		t.Run("frozen rows are ignored when the option is not set", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[1], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+			require.NoError(t, err)
+			assert.Equal(t, "Field 1", frame.Fields[0].Name)
+			assert.Equal(t, 3, frame.Fields[1].Len())
+		})
+	})
+
+	t.Run("times are parsed in the time zone of the spreadsheet", func(t *testing.T) {
+		local := time.Local
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		frame, err := gsd.querySheet(context.Background(), &fakeClient{}, "A", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		parsed := frame.Fields[0].At(0).(*time.Time)
+		assert.Equal(t, "Europe/Stockholm", parsed.Location().String())
+		assert.Equal(t, 2020, parsed.Year())
+		assert.Equal(t, local, time.Local, "the process time zone is not changed")
+	})
+
+	t.Run("time as epoch millis", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/mixed-data.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		meta := make(map[string]interface{})
+		timeFrame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		require.Equal(t, data.FieldTypeNullableTime, timeFrame.Fields[0].Type())
+
+		meta = make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TimeAsEpochMillis: true}
+		frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[0]
+		require.Equal(t, data.FieldTypeNullableInt64, field.Type())
+		assert.Equal(t, "dateTimeAsIso", field.Config.Unit)
+		for i := 0; i < field.Len(); i++ {
+			expected := timeFrame.Fields[0].At(i).(*time.Time)
+			millis := field.At(i).(*int64)
+			if expected == nil {
+				assert.Nil(t, millis)
+				continue
+			}
+			assert.Equal(t, expected.UnixNano()/int64(time.Millisecond), *millis)
+		}
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local).UnixNano()/int64(time.Millisecond), *field.At(0).(*int64))
+
+		assert.EqualError(t, validateQuery(&models.QueryModel{UseTimeFilter: true, TimeAsEpochMillis: true}),
+			"the time filter needs time values, not epoch milliseconds")
+		assert.EqualError(t, validateQuery(&models.QueryModel{Filter: "$__timeFilter(Time)", TimeAsEpochMillis: true}),
+			"the time filter needs time values, not epoch milliseconds")
+	})
+
+	t.Run("row header column", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/matrix.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", RowHeaderColumn: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, "Row", frame.Fields[0].Name)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type())
+		assert.Equal(t, "South", *frame.Fields[0].At(1).(*string))
+
+		for i, name := range []string{"Q1", "Q2", "Q3"} {
+			field := frame.Fields[i+1]
+			assert.Equal(t, name, field.Name)
+			assert.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+		}
+		assert.Equal(t, 9.0, *fieldByName(t, frame, "Q2").At(1).(*float64))
+		assert.Equal(t, 6.0, *fieldByName(t, frame, "Q3").At(2).(*float64))
+
+		t.Run("can not be combined with label column", func(t *testing.T) {
+			assert.Error(t, validateQuery(&models.QueryModel{RowHeaderColumn: true, LabelColumn: "Q1"}))
+		})
+
+		t.Run("matrix produces a heatmap grid", func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", Matrix: true}
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+			require.NoError(t, err)
+
+			require.Len(t, frame.Fields, 4)
+			assert.Equal(t, "Row", frame.Fields[0].Name)
+			assert.Equal(t, "South", *frame.Fields[0].At(1).(*string))
+			assert.Equal(t, 9.0, *fieldByName(t, frame, "Q2").At(1).(*float64))
+			assert.Equal(t, data.FieldTypeNullableFloat64, fieldByName(t, frame, "Q3").Type())
+
+			assert.Error(t, validateQuery(&models.QueryModel{Matrix: true, LabelColumn: "Q1"}))
+			assert.Error(t, validateQuery(&models.QueryModel{Matrix: true, PivotColumn: "Q1"}))
+		})
+	})
+
+	t.Run("type detection sample size", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/late-outlier.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		t.Run("a late outlier makes the whole column a string", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+			require.NoError(t, err)
+			assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		})
+
+		t.Run("values outside the sample are coerced to the sampled type", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid", TypeDetectionSampleSize: 10})
+			require.NoError(t, err)
+
+			field := frame.Fields[1]
+			require.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+			assert.Equal(t, 29.0, *field.At(19).(*float64))
+			assert.Nil(t, field.At(20))
+			assert.Contains(t, meta["warnings"], "expected numeric value but got 'timeout'")
+		})
+	})
+
+	t.Run("time offset", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/mixed-data.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		meta := make(map[string]interface{})
+		original, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		t.Run("offset is applied to time fields", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid", TimeOffset: "-5h"})
+			require.NoError(t, err)
+
+			for _, name := range []string{"Date", "Datetime"} {
+				expected := fieldByName(t, original, name).At(0).(*time.Time).Add(-5 * time.Hour)
+				assert.Equal(t, expected, *fieldByName(t, frame, name).At(0).(*time.Time))
+			}
+			assert.Equal(t, *fieldByName(t, original, "Number").At(0).(*float64), *fieldByName(t, frame, "Number").At(0).(*float64))
+		})
+
+		t.Run("offset is applied to epoch millis", func(t *testing.T) {
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid", TimeOffset: "90m", TimeAsEpochMillis: true})
+			require.NoError(t, err)
+
+			expected := fieldByName(t, original, "Date").At(0).(*time.Time).Add(90 * time.Minute)
+			assert.Equal(t, expected.UnixNano()/int64(time.Millisecond), *fieldByName(t, frame, "Date").At(0).(*int64))
+		})
+
+		t.Run("invalid offset is rejected", func(t *testing.T) {
+			assert.Error(t, validateQuery(&models.QueryModel{TimeOffset: "5 hours"}))
+		})
+	})
+
+	t.Run("custom frame name", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/single-cell.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Range: "A2", Spreadsheet: "someid", FrameName: "Hot dogs"}
+
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, "Hot dogs", frame.Name)
+		assert.Equal(t, "ref1", frame.RefID)
+	})
+
+	t.Run("label column", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/label-series.json")
+		require.NoError(t, err)
+
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		t.Run("label is a string and series are numeric", func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", LabelColumn: "Region"}
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+			require.NoError(t, err)
+			require.Equal(t, 4, len(frame.Fields))
+
+			assert.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type())
+			for _, field := range frame.Fields[1:] {
+				assert.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+				assert.Equal(t, 4, field.Len())
+			}
+
+			label, ok := frame.Fields[0].At(0).(*string)
+			require.True(t, ok)
+			assert.Equal(t, "North", *label)
+
+			value, ok := frame.Fields[3].At(0).(*float64)
+			require.True(t, ok)
+			assert.Equal(t, 150.0, *value)
+		})
+
+		t.Run("non-numeric series values become null with warnings", func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", LabelColumn: "Region"}
+			meta := make(map[string]interface{})
+			frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+			require.NoError(t, err)
+
+			assert.Nil(t, frame.Fields[2].At(1))
+			assert.Nil(t, frame.Fields[1].At(3))
+			warnings := meta["warnings"].([]string)
+			assert.Equal(t, 2, len(warnings))
+		})
+
+		t.Run("explicit column types are kept", func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", LabelColumn: "Region", ColumnTypes: map[string]string{"Q2": "string"}}
+			frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+			require.NoError(t, err)
+
+			assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+			require.Equal(t, data.FieldTypeNullableString, frame.Fields[2].Type())
+			assert.Equal(t, "n/a", *frame.Fields[2].At(1).(*string))
+		})
+
+		t.Run("missing label column returns an error", func(t *testing.T) {
+			qm := models.QueryModel{Spreadsheet: "someid", LabelColumn: "Country"}
+			meta := make(map[string]interface{})
+			_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+			require.Error(t, err)
+		})
+	})
+
 	t.Run("column id formatting", func(t *testing.T) {
 		require.Equal(t, "A", getExcelColumnName(1))
 		require.Equal(t, "B", getExcelColumnName(2))
@@ -165,4 +843,69 @@ func TestGooglesheets(t *testing.T) {
 		require.Equal(t, "ZW", getExcelColumnName(699))
 		require.Equal(t, "AJIL", getExcelColumnName(24582))
 	})
+
+	t.Run("grid range to A1", func(t *testing.T) {
+		assert.Equal(t, "'Sheet1'!A1:C10", gridRangeToA1("Sheet1", &sheets.GridRange{EndRowIndex: 10, EndColumnIndex: 3}))
+		assert.Equal(t, "'Sheet1'!B:B", gridRangeToA1("Sheet1", &sheets.GridRange{StartColumnIndex: 1, EndColumnIndex: 2}))
+		assert.Equal(t, "'Sheet1'!2:5", gridRangeToA1("Sheet1", &sheets.GridRange{StartRowIndex: 1, EndRowIndex: 5}))
+		assert.Equal(t, "'Sheet1'", gridRangeToA1("Sheet1", &sheets.GridRange{}))
+		assert.Equal(t, "'My ''Q1'' Sheet'!A1:B2", gridRangeToA1("My 'Q1' Sheet", &sheets.GridRange{EndRowIndex: 2, EndColumnIndex: 2}))
+	})
+}
+
+func BenchmarkTransformSheetToDataFrame(b *testing.B) {
+	spreadsheet, err := loadTestSheet("./testdata/late-outlier.json")
+	require.NoError(b, err)
+
+	// Grow the sheet so type detection dominates
+	grid := spreadsheet.Sheets[0].Data[0]
+	rows := grid.RowData[1:]
+	for len(grid.RowData) < 10000 {
+		grid.RowData = append(grid.RowData, rows...)
+	}
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	for _, sampleSize := range []int{0, 100} {
+		b.Run(fmt.Sprintf("sample size %d", sampleSize), func(b *testing.B) {
+			qm := models.QueryModel{Spreadsheet: "someid", TypeDetectionSampleSize: sampleSize}
+			for i := 0; i < b.N; i++ {
+				meta := make(map[string]interface{})
+				if _, err := gsd.transformSheetToDataFrame(spreadsheet.Sheets[0], meta, "ref1", &qm); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorOnEmpty(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/empty-range.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("empty ranges return an empty frame by default", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Log!A1:B"}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		frames, err := finishFrames([]*data.Frame{frame}, &qm, backend.TimeRange{})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, 0, frames[0].Rows())
+	})
+
+	t.Run("empty ranges fail the query with error on empty", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Log!A1:B", ErrorOnEmpty: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		_, err = finishFrames([]*data.Frame{frame}, &qm, backend.TimeRange{})
+		assert.True(t, errors.Is(err, ErrEmptyRange), "unexpected error: %v", err)
+		assert.EqualError(t, err, `no data rows in range "Log!A1:B": range not found or empty`)
+	})
 }
@@ -0,0 +1,147 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// aggregations are the functions a grouped column can be aggregated with.
+var aggregations = map[string]bool{"sum": true, "avg": true, "count": true, "min": true, "max": true}
+
+func validateGroupBy(qm *models.QueryModel) error {
+	if qm.GroupByColumn == "" {
+		if len(qm.Aggregations) > 0 {
+			return fmt.Errorf("aggregations require a group by column")
+		}
+		return nil
+	}
+	if qm.PivotColumn != "" {
+		return fmt.Errorf("group by column and pivot column can not be used together")
+	}
+	for column, aggregation := range qm.Aggregations {
+		if !aggregations[aggregation] {
+			return fmt.Errorf("unknown aggregation %q for column %q, expected sum, avg, count, min or max", aggregation, column)
+		}
+	}
+	return nil
+}
+
+// groupFrame returns a frame with one row per distinct value of the group column, in the order the values first
+// appear, and a field per aggregated column. Rows without a group value are left out. Count is the only
+// aggregation of columns that are not numbers.
+func groupFrame(frame *data.Frame, groupColumn string, columnAggregations map[string]string) (*data.Frame, error) {
+	groupIndex := frameFieldIndex(frame, groupColumn)
+	if groupIndex < 0 {
+		return nil, fmt.Errorf("group by column %q not found", groupColumn)
+	}
+
+	columns := make([]string, 0, len(columnAggregations))
+	for column := range columnAggregations {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		indexes[i] = frameFieldIndex(frame, column)
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("aggregated column %q not found", column)
+		}
+		if columnAggregations[column] != "count" && !frame.Fields[indexes[i]].Type().Numeric() {
+			return nil, fmt.Errorf("aggregated column %q is not a number column, only count is supported", column)
+		}
+	}
+
+	groupRows := [][]int{}
+	groupByKey := map[string]int{}
+	for row := 0; row < frame.Rows(); row++ {
+		key, ok := formatFieldValue(frame.Fields[groupIndex], row)
+		if !ok {
+			continue
+		}
+		group, exists := groupByKey[key]
+		if !exists {
+			group = len(groupRows)
+			groupByKey[key] = group
+			groupRows = append(groupRows, nil)
+		}
+		groupRows[group] = append(groupRows[group], row)
+	}
+
+	grouped := data.NewFrame(frame.Name)
+	grouped.RefID = frame.RefID
+	source := frame.Fields[groupIndex]
+	groupField := data.NewFieldFromFieldType(source.Type(), len(groupRows))
+	groupField.Name = source.Name
+	groupField.Config = source.Config
+	for i, rows := range groupRows {
+		groupField.Set(i, source.CopyAt(rows[0]))
+	}
+	grouped.Fields = append(grouped.Fields, groupField)
+
+	for i, column := range columns {
+		field, err := aggregateField(frame.Fields[indexes[i]], columnAggregations[column], groupRows)
+		if err != nil {
+			return nil, err
+		}
+		grouped.Fields = append(grouped.Fields, field)
+	}
+	return grouped, nil
+}
+
+// aggregateField aggregates the non-null values of each group of rows. Counts are integers, the other
+// aggregations are null for groups without any values.
+func aggregateField(source *data.Field, aggregation string, groupRows [][]int) (*data.Field, error) {
+	if aggregation == "count" {
+		counts := make([]int64, len(groupRows))
+		for i, rows := range groupRows {
+			for _, row := range rows {
+				if _, ok := source.ConcreteAt(row); ok {
+					counts[i]++
+				}
+			}
+		}
+		field := data.NewField(source.Name, nil, counts)
+		field.Config = &data.FieldConfig{DisplayName: source.Name}
+		return field, nil
+	}
+
+	values := make([]*float64, len(groupRows))
+	for i, rows := range groupRows {
+		count := 0
+		result := 0.0
+		for _, row := range rows {
+			if _, ok := source.ConcreteAt(row); !ok {
+				continue
+			}
+			value, err := source.FloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case count == 0 && aggregation != "sum" && aggregation != "avg":
+				result = value
+			case aggregation == "min":
+				result = math.Min(result, value)
+			case aggregation == "max":
+				result = math.Max(result, value)
+			default:
+				result += value
+			}
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		if aggregation == "avg" {
+			result /= float64(count)
+		}
+		values[i] = &result
+	}
+	field := data.NewField(source.Name, nil, values)
+	field.Config = source.Config
+	return field, nil
+}
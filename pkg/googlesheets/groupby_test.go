@@ -0,0 +1,66 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupBy(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/expenses.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("values are summed per category", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", GroupByColumn: "Category", Aggregations: map[string]string{"Amount": "sum", "Note": "count"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 2, frame.Rows(), "rows without a category are left out")
+		assert.Equal(t, "Food", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "Travel", *frame.Fields[0].At(1).(*string))
+		assert.Equal(t, 25.0, *frame.Fields[1].At(0).(*float64))
+		assert.Equal(t, 40.0, *frame.Fields[1].At(1).(*float64))
+		assert.Equal(t, int64(2), frame.Fields[2].At(0))
+		assert.Equal(t, int64(2), frame.Fields[2].At(1))
+	})
+
+	t.Run("values are averaged, ignoring empty cells", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", GroupByColumn: "Category", Aggregations: map[string]string{"Amount": "avg"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+		assert.InDelta(t, 25.0/3, *frame.Fields[1].At(0).(*float64), 1e-9)
+		assert.Equal(t, 40.0, *frame.Fields[1].At(1).(*float64))
+	})
+
+	t.Run("minimum and maximum of a group", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", GroupByColumn: "Category", Aggregations: map[string]string{"Amount": "max"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 12.5, *frame.Fields[1].At(0).(*float64))
+
+		qm.Aggregations = map[string]string{"Amount": "min"}
+		frame, err = gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 5.0, *frame.Fields[1].At(0).(*float64))
+	})
+
+	t.Run("string columns can only be counted", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", GroupByColumn: "Category", Aggregations: map[string]string{"Note": "sum"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, `aggregated column "Note" is not a number column, only count is supported`)
+	})
+
+	t.Run("invalid options are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{GroupByColumn: "Category", Aggregations: map[string]string{"Amount": "median"}}))
+		assert.Error(t, validateQuery(&models.QueryModel{Aggregations: map[string]string{"Amount": "sum"}}))
+		assert.Error(t, validateQuery(&models.QueryModel{GroupByColumn: "Category", PivotColumn: "Note", PivotValueColumn: "Amount"}))
+	})
+}
@@ -0,0 +1,82 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"google.golang.org/api/sheets/v4"
+)
+
+// withHeaderRange returns a copy of the sheet with the first row of the header range on top of its data, so that
+// transformSheetToDataFrame names the columns after it. A header range without a sheet name is on the sheet of the data.
+func (gs *GoogleSheets) withHeaderRange(ctx context.Context, client client, qm *models.QueryModel, sheet *sheets.Sheet) (*sheets.Sheet, string, error) {
+	headerRange := qm.HeaderRange
+	if !hasSheetName(headerRange) && hasSheetName(qm.Range) {
+		headerRange = quoteSheetName(sheetNameOf(qm.Range)) + "!" + headerRange
+	}
+	headerQuery := models.QueryModel{
+		Spreadsheet:          qm.Spreadsheet,
+		Range:                headerRange,
+		CacheDurationSeconds: qm.CacheDurationSeconds,
+		ErrorCacheDuration:   qm.ErrorCacheDuration,
+		CacheJitter:          qm.CacheJitter,
+		CacheSizeTiers:       qm.CacheSizeTiers,
+	}
+	spreadsheet, _, err := gs.getSheetData(ctx, client, &headerQuery)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read header range %q: %w", qm.HeaderRange, err)
+	}
+	headerSheet := spreadsheet.Sheets[0]
+	if len(headerSheet.Data) == 0 || len(headerSheet.Data[0].RowData) == 0 {
+		return nil, "", fmt.Errorf("no headers found in header range %q", qm.HeaderRange)
+	}
+	if len(sheet.Data) == 0 {
+		return sheet, "", nil
+	}
+
+	merged, warning := mergeHeaderRow(headerSheet.Data[0].RowData[0], sheet.Data[0])
+	withHeader := *sheet
+	withHeader.Data = []*sheets.GridData{merged}
+	return &withHeader, warning, nil
+}
+
+// mergeHeaderRow puts the header row on top of the grid data, padded or truncated to the width of the data.
+// The data rows keep their sheet row numbers. The grid data itself is not modified, since it may be cached.
+func mergeHeaderRow(header *sheets.RowData, grid *sheets.GridData) (*sheets.GridData, string) {
+	columnCount := 0
+	for _, row := range grid.RowData {
+		if len(row.Values) > columnCount {
+			columnCount = len(row.Values)
+		}
+	}
+
+	warning := ""
+	values := header.Values
+	switch {
+	case len(values) < columnCount:
+		warning = fmt.Sprintf("Header range has %d columns but the data has %d, the others are named after their column", len(values), columnCount)
+		padded := make([]*sheets.CellData, columnCount)
+		copy(padded, values)
+		for i := len(values); i < columnCount; i++ {
+			padded[i] = &sheets.CellData{}
+		}
+		values = padded
+	case len(values) > columnCount:
+		warning = fmt.Sprintf("Header range has %d columns but the data has %d, the extra headers are ignored", len(values), columnCount)
+		values = values[:columnCount]
+	}
+
+	rows := append([]*sheets.RowData{{Values: values}}, grid.RowData...)
+	rowMetadata := grid.RowMetadata
+	if len(rowMetadata) > 0 {
+		rowMetadata = append([]*sheets.DimensionProperties{{}}, rowMetadata...)
+	}
+	return &sheets.GridData{
+		RowData:        rows,
+		RowMetadata:    rowMetadata,
+		ColumnMetadata: grid.ColumnMetadata,
+		StartRow:       grid.StartRow - 1,
+		StartColumn:    grid.StartColumn,
+	}, warning
+}
@@ -0,0 +1,86 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestHeaderRange(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/header-range.json")
+	require.NoError(t, err)
+	byTitle := map[string]*sheets.Spreadsheet{}
+	for _, sheet := range spreadsheet.Sheets {
+		single := *spreadsheet
+		single.Sheets = []*sheets.Sheet{sheet}
+		byTitle[sheet.Properties.Title] = &single
+	}
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"Data!A5:C7":    byTitle["Data"],
+		"'Data'!A1:C1":  byTitle["Headers"],
+		"Headers!A1:C1": byTitle["Headers"],
+		"Headers!A1:B1": byTitle["Short"],
+		"Headers!A1:D1": byTitle["Long"],
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	fieldNames := func(frame *data.Frame) []string {
+		names := []string{}
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	t.Run("headers are read from their own range", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data!A5:C7", HeaderRange: "Headers!A1:C1"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Region", "Sales", "Units"}, fieldNames(frame))
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "EMEA", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, 200.0, *frame.Fields[1].At(2).(*float64))
+		assert.Empty(t, frame.Meta.Custom.(map[string]interface{})["warnings"])
+	})
+
+	t.Run("header ranges without a sheet are on the sheet of the data", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data!A5:C7", HeaderRange: "A1:C1"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Region", "Sales", "Units"}, fieldNames(frame))
+	})
+
+	t.Run("short headers are padded with column names", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data!A5:C7", HeaderRange: "Headers!A1:B1"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Region", "Sales", "Field 3"}, fieldNames(frame))
+		assert.Equal(t, 4.0, *frame.Fields[2].At(0).(*float64))
+		assert.Contains(t, frame.Meta.Custom.(map[string]interface{})["warnings"], "Header range has 2 columns but the data has 3, the others are named after their column")
+	})
+
+	t.Run("extra headers are ignored", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data!A5:C7", HeaderRange: "Headers!A1:D1"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Region", "Sales", "Units"}, fieldNames(frame))
+		assert.Contains(t, frame.Meta.Custom.(map[string]interface{})["warnings"], "Header range has 4 columns but the data has 3, the extra headers are ignored")
+	})
+
+	t.Run("the cached data is not modified", func(t *testing.T) {
+		assert.Equal(t, 3, len(byTitle["Data"].Sheets[0].Data[0].RowData))
+		assert.Equal(t, int64(4), byTitle["Data"].Sheets[0].Data[0].StartRow)
+	})
+}
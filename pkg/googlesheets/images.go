@@ -0,0 +1,51 @@
+package googlesheets
+
+import (
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// imageFormula matches the url argument of an IMAGE() formula.
+var imageFormula = regexp.MustCompile(`(?i)^=\s*IMAGE\(\s*"([^"]+)"`)
+
+// getImageURL returns the url of an IMAGE() formula cell. The API has no value for images inserted into a
+// cell through the Sheets UI, so those are not found.
+func getImageURL(cell *sheets.CellData) *string {
+	if cell == nil || cell.UserEnteredValue == nil || cell.UserEnteredValue.FormulaValue == nil {
+		return nil
+	}
+
+	match := imageFormula.FindStringSubmatch(*cell.UserEnteredValue.FormulaValue)
+	if match == nil {
+		return nil
+	}
+	return &match[1]
+}
+
+// getImageFields creates a <column>_image field for every column that contains at least one image.
+func getImageFields(rows []*sheets.RowData, columns []*ColumnDefinition) []*data.Field {
+	fields := []*data.Field{}
+	for _, column := range columns {
+		urls := make([]*string, len(rows))
+		found := false
+		for rowIndex, row := range rows {
+			if column.ColumnIndex >= len(row.Values) {
+				continue
+			}
+			if url := getImageURL(row.Values[column.ColumnIndex]); url != nil {
+				urls[rowIndex] = url
+				found = true
+			}
+		}
+
+		if found {
+			name := column.Header + "_image"
+			field := data.NewField(name, nil, urls)
+			field.Config = &data.FieldConfig{DisplayName: name}
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,50 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImages(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/images.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("image fields are added when ExtractImages is set", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ExtractImages: true}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(frame.Fields))
+		assert.Equal(t, "Logo_image", frame.Fields[2].Name)
+
+		field := frame.Fields[2]
+		require.Equal(t, 4, field.Len())
+		url, ok := field.At(0).(*string)
+		require.True(t, ok)
+		assert.Equal(t, "https://grafana.com/static/img/logo.svg", *url)
+
+		url, ok = field.At(1).(*string)
+		require.True(t, ok)
+		assert.Equal(t, "https://prometheus.io/assets/logo.png", *url)
+
+		assert.Nil(t, field.At(2), "the API returns an empty cell for an image inserted through the Sheets UI")
+		assert.Nil(t, field.At(3))
+	})
+
+	t.Run("image fields are not added by default", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid"}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 2, len(frame.Fields))
+	})
+}
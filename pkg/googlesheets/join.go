@@ -0,0 +1,156 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func validateJoin(qm *models.QueryModel) error {
+	if qm.JoinRange != "" && len(qm.JoinKeys) == 0 {
+		return fmt.Errorf("join range requires at least one join key")
+	}
+	if qm.JoinRange == "" && len(qm.JoinKeys) > 0 {
+		return fmt.Errorf("join keys require a join range")
+	}
+	return nil
+}
+
+// joinRange reads the join range and left joins it onto the frame. Only the options on how the cells are read
+// carry over to the join range; the ones naming columns or reshaping the rows are for the joined frame.
+func (gs *GoogleSheets) joinRange(ctx context.Context, client client, refID string, qm *models.QueryModel, frame *data.Frame) (*data.Frame, error) {
+	joinQuery := models.QueryModel{
+		Spreadsheet:             qm.Spreadsheet,
+		Range:                   qm.JoinRange,
+		RevisionID:              qm.RevisionID,
+		CacheDurationSeconds:    qm.CacheDurationSeconds,
+		ErrorCacheDuration:      qm.ErrorCacheDuration,
+		CacheJitter:             qm.CacheJitter,
+		CacheSizeTiers:          qm.CacheSizeTiers,
+		UseFrozenRowsAsHeader:   qm.UseFrozenRowsAsHeader,
+		DuplicateSuffixFormat:   qm.DuplicateSuffixFormat,
+		CaseInsensitiveColumns:  qm.CaseInsensitiveColumns,
+		TypeDetectionSampleSize: qm.TypeDetectionSampleSize,
+		ColumnTypes:             qm.ColumnTypes,
+		TimeAsEpochMillis:       qm.TimeAsEpochMillis,
+		Location:                qm.Location,
+		TrimStrings:             qm.TrimStrings,
+		NullTokens:              qm.NullTokens,
+		NullableFields:          qm.NullableFields,
+		EmptyValue:              qm.EmptyValue,
+		ErrorCellPolicy:         qm.ErrorCellPolicy,
+		NewlineHandling:         qm.NewlineHandling,
+		TextPercentages:         qm.TextPercentages,
+		PercentAsFraction:       qm.PercentAsFraction,
+	}
+	right, err := gs.querySheet(ctx, client, refID, &joinQuery)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read join range %q: %w", qm.JoinRange, err)
+	}
+
+	keys := qm.JoinKeys
+	if qm.CaseInsensitiveColumns {
+		if keys, err = newFrameColumnNameResolver(frame).resolveAll(qm.JoinKeys); err != nil {
+			return nil, err
+		}
+		// The key fields of the join range are named like the ones of the frame, they are left out of the join
+		rightResolver := newFrameColumnNameResolver(right)
+		for _, key := range keys {
+			header, err := rightResolver.resolve(key)
+			if err != nil {
+				return nil, err
+			}
+			if index := frameFieldIndex(right, header); index >= 0 {
+				right.Fields[index].Name = key
+			}
+		}
+	}
+
+	joined, err := joinFrames(frame, right, keys)
+	if err != nil {
+		return nil, err
+	}
+	joined.Meta = frame.Meta
+	return joined, nil
+}
+
+// compositeKey joins the formatted key values of a row. Rows with a null key part have no key.
+func compositeKey(frame *data.Frame, keyIndexes []int, row int) (string, bool) {
+	parts := make([]string, len(keyIndexes))
+	for i, index := range keyIndexes {
+		part, ok := formatFieldValue(frame.Fields[index], row)
+		if !ok {
+			return "", false
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// joinFrames left joins right onto left on the key fields. Every left row is kept, and the
+// non-key fields of right are null for rows without a match.
+func joinFrames(left *data.Frame, right *data.Frame, keys []string) (*data.Frame, error) {
+	leftKeys := make([]int, len(keys))
+	rightKeys := make([]int, len(keys))
+	isKey := map[int]bool{}
+	for i, key := range keys {
+		leftKeys[i] = frameFieldIndex(left, key)
+		if leftKeys[i] < 0 {
+			return nil, fmt.Errorf("join key %q not found", key)
+		}
+		rightKeys[i] = frameFieldIndex(right, key)
+		if rightKeys[i] < 0 {
+			return nil, fmt.Errorf("join key %q not found in join range", key)
+		}
+		leftType, rightType := left.Fields[leftKeys[i]].Type(), right.Fields[rightKeys[i]].Type()
+		if leftType != rightType {
+			return nil, fmt.Errorf("join key %q has type %s, but %s in join range", key, leftType, rightType)
+		}
+		isKey[rightKeys[i]] = true
+	}
+
+	rightRows := map[string]int{}
+	for row := 0; row < right.Rows(); row++ {
+		if key, ok := compositeKey(right, rightKeys, row); ok {
+			if _, exists := rightRows[key]; !exists {
+				rightRows[key] = row
+			}
+		}
+	}
+
+	joined := data.NewFrame(left.Name)
+	joined.RefID = left.RefID
+	joined.Fields = append(joined.Fields, left.Fields...)
+
+	names := map[string]bool{}
+	for _, field := range left.Fields {
+		names[field.Name] = true
+	}
+	for index, source := range right.Fields {
+		if isKey[index] {
+			continue
+		}
+
+		field := data.NewFieldFromFieldType(source.Type(), left.Rows())
+		field.Name = getUniqueColumnName(source.Name, index, names, "")
+		names[field.Name] = true
+		field.Config = &data.FieldConfig{DisplayName: field.Name}
+		if source.Config != nil {
+			field.Config.Unit = source.Config.Unit
+		}
+		for row := 0; row < left.Rows(); row++ {
+			key, ok := compositeKey(left, leftKeys, row)
+			if !ok {
+				continue
+			}
+			if match, found := rightRows[key]; found {
+				field.Set(row, source.CopyAt(match))
+			}
+		}
+		joined.Fields = append(joined.Fields, field)
+	}
+	return joined, nil
+}
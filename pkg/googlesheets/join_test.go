@@ -0,0 +1,94 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestJoin(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/join.json")
+	require.NoError(t, err)
+
+	sales, targets := *spreadsheet, *spreadsheet
+	sales.Sheets = spreadsheet.Sheets[:1]
+	targets.Sheets = spreadsheet.Sheets[1:]
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"":        spreadsheet,
+		"Sales":   &sales,
+		"Targets": &targets,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("ranges are joined on a composite key", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sales", JoinRange: "Targets", JoinKeys: []string{"Region", "Date"}}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 4)
+		require.Equal(t, 4, frame.Rows())
+		target := fieldByName(t, frame, "Target")
+		assert.Equal(t, 11.0, *target.At(0).(*float64))
+		assert.Nil(t, target.At(1))
+		assert.Nil(t, target.At(2))
+		assert.Equal(t, 10.0, *target.At(3).(*float64))
+		assert.Equal(t, 9.0, *fieldByName(t, frame, "Sales").At(3).(*float64))
+		assert.NotNil(t, frame.Meta)
+	})
+
+	t.Run("join keys match ignoring case with case insensitive columns", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sales", JoinRange: "Targets", JoinKeys: []string{"region", "DATE"}, CaseInsensitiveColumns: true}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, 11.0, *fieldByName(t, frame, "Target").At(0).(*float64))
+	})
+
+	t.Run("options on the columns of the left range are not applied to the join range", func(t *testing.T) {
+		qm := models.QueryModel{
+			Spreadsheet:    "someid",
+			Range:          "Sales",
+			JoinRange:      "Targets",
+			JoinKeys:       []string{"Region", "Date"},
+			LabelColumn:    "Region",
+			ColumnTypes:    map[string]string{"Date": "string"},
+			DerivedColumns: []models.DerivedColumn{{Name: "Double", Expression: "Sales * 2"}},
+			ConstantFields: map[string]string{"Source": "sheet"},
+		}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, 20.0, *fieldByName(t, frame, "Double").At(0).(*float64))
+		assert.Equal(t, 11.0, *fieldByName(t, frame, "Target").At(0).(*float64))
+		assert.Equal(t, data.FieldTypeNullableString, fieldByName(t, frame, "Date").Type(), "reading options still apply to both")
+		assert.Len(t, frame.Fields, 6, "constant fields are added once")
+	})
+
+	t.Run("join keys must have the same type on both sides", func(t *testing.T) {
+		left := data.NewFrame("left",
+			data.NewField("Region", nil, []*string{}),
+			data.NewField("Date", nil, []*string{}),
+		)
+		right := data.NewFrame("right",
+			data.NewField("Region", nil, []*string{}),
+			data.NewField("Date", nil, []*time.Time{}),
+		)
+		_, err := joinFrames(left, right, []string{"Region", "Date"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `join key "Date"`)
+	})
+
+	t.Run("join range requires keys", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{JoinRange: "Targets"}))
+	})
+}
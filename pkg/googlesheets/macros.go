@@ -0,0 +1,52 @@
+package googlesheets
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// timeFilterMacro matches $__timeFilter(column), where the column name may be quoted.
+var timeFilterMacro = regexp.MustCompile(`^\s*\$__timeFilter\(\s*(.*?)\s*\)\s*$`)
+
+// parseTimeFilter returns the column of a $__timeFilter macro.
+func parseTimeFilter(filter string) (string, error) {
+	match := timeFilterMacro.FindStringSubmatch(filter)
+	if match == nil {
+		return "", fmt.Errorf("invalid filter %q, expected $__timeFilter(column)", filter)
+	}
+
+	column := match[1]
+	if len(column) >= 2 && (column[0] == '"' || column[0] == '\'') && column[len(column)-1] == column[0] {
+		column = column[1 : len(column)-1]
+	}
+	if column == "" {
+		return "", fmt.Errorf("invalid filter %q, $__timeFilter needs a column", filter)
+	}
+	return column, nil
+}
+
+// applyFilter keeps the rows of the frame matching the filter macro, using the time range of the request.
+// With caseInsensitive the column matches the field names ignoring case.
+func applyFilter(frame *data.Frame, filter string, timeRange backend.TimeRange, caseInsensitive bool) (*data.Frame, error) {
+	column, err := parseTimeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if caseInsensitive {
+		if column, err = newFrameColumnNameResolver(frame).resolve(column); err != nil {
+			return nil, err
+		}
+	}
+
+	index := frameFieldIndex(frame, column)
+	if index < 0 {
+		return nil, fmt.Errorf("time filter column %q not found", column)
+	}
+	if fieldType := frame.Fields[index].Type(); fieldType != data.FieldTypeNullableTime && fieldType != data.FieldTypeTime {
+		return nil, fmt.Errorf("time filter column %q is not a time column", column)
+	}
+	return filterFieldByTimeRange(frame, index, timeRange)
+}
@@ -0,0 +1,81 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeFilterMacro(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/time-formula.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+	timeRange := backend.TimeRange{
+		From: time.Date(2020, 4, 8, 16, 59, 30, 0, time.Local),
+		To:   time.Date(2020, 4, 8, 17, 2, 0, 0, time.Local),
+	}
+	query := func(t *testing.T, qm *models.QueryModel) []*data.Frame {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", qm)
+		require.NoError(t, err)
+		frames, err := finishFrames([]*data.Frame{frame}, qm, timeRange)
+		require.NoError(t, err)
+		return frames
+	}
+
+	t.Run("rows outside of the time range are dropped", func(t *testing.T) {
+		frames := query(t, &models.QueryModel{Spreadsheet: "someid", Filter: "$__timeFilter(Time)"})
+		require.Len(t, frames, 1)
+		require.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, time.Date(2020, 4, 8, 17, 0, 14, 0, time.Local), *frames[0].Fields[0].At(0).(*time.Time))
+		assert.Equal(t, 3.0, *frames[0].Fields[1].At(1).(*float64))
+	})
+
+	t.Run("column names can be quoted", func(t *testing.T) {
+		frames := query(t, &models.QueryModel{Spreadsheet: "someid", Filter: ` $__timeFilter( "Time" ) `})
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+
+	t.Run("non-nullable time fields can be filtered", func(t *testing.T) {
+		nullable := false
+		frames := query(t, &models.QueryModel{Spreadsheet: "someid", NullableFields: &nullable, Filter: "$__timeFilter(Time)"})
+		require.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, time.Date(2020, 4, 8, 17, 0, 14, 0, time.Local), frames[0].Fields[0].At(0))
+
+		frames = query(t, &models.QueryModel{Spreadsheet: "someid", NullableFields: &nullable, UseTimeFilter: true})
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+
+	t.Run("the column matches ignoring case with case insensitive columns", func(t *testing.T) {
+		frames := query(t, &models.QueryModel{Spreadsheet: "someid", Filter: "$__timeFilter(time)", CaseInsensitiveColumns: true})
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+
+	t.Run("rows are kept without a filter", func(t *testing.T) {
+		frames := query(t, &models.QueryModel{Spreadsheet: "someid"})
+		assert.Equal(t, 3, frames[0].Rows())
+	})
+
+	t.Run("the column must be a time column", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{})
+		require.NoError(t, err)
+
+		_, err = applyFilter(frame, "$__timeFilter(Fish)", timeRange, false)
+		assert.EqualError(t, err, `time filter column "Fish" is not a time column`)
+		_, err = applyFilter(frame, "$__timeFilter(Missing)", timeRange, false)
+		assert.EqualError(t, err, `time filter column "Missing" not found`)
+	})
+
+	t.Run("invalid filters are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{Filter: "Time > now()"}))
+		assert.Error(t, validateQuery(&models.QueryModel{Filter: "$__timeFilter()"}))
+		assert.NoError(t, validateQuery(&models.QueryModel{Filter: "$__timeFilter(Time)"}))
+	})
+}
@@ -0,0 +1,294 @@
+package googlesheets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// getSpreadsheetMetadata gets a spreadsheet without any grid data.
+func (gs *GoogleSheets) getSpreadsheetMetadata(ctx context.Context, client client, qm *models.QueryModel) (*sheets.Spreadsheet, map[string]interface{}, error) {
+	cacheKey := "metadata:" + qm.Spreadsheet + qm.Range
+	if item, expires, found := gs.Cache.GetWithExpiration(cacheKey); found && qm.CacheDurationSeconds > 0 {
+		return item.(*sheets.Spreadsheet), map[string]interface{}{
+			"hit":     true,
+			"expires": expires.Unix(),
+		}, nil
+	}
+
+	result, err := client.GetSpreadsheet(ctx, qm.Spreadsheet, qm.Range, false, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if qm.CacheDurationSeconds > 0 {
+		gs.Cache.Set(cacheKey, result, time.Duration(qm.CacheDurationSeconds)*time.Second)
+	}
+
+	return result, map[string]interface{}{"hit": false}, nil
+}
+
+// getConditionalFormats returns a frame with one row per conditional format rule.
+func (gs *GoogleSheets) getConditionalFormats(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	spreadsheet, meta, err := gs.getSpreadsheetMetadata(ctx, client, qm)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetNames := []string{}
+	ranges := []string{}
+	ruleTypes := []string{}
+	conditions := []string{}
+	formats := []string{}
+	for _, sheet := range spreadsheet.Sheets {
+		for _, rule := range sheet.ConditionalFormats {
+			a1 := make([]string, len(rule.Ranges))
+			for i, gridRange := range rule.Ranges {
+				a1[i] = gridRangeToA1(sheet.Properties.Title, gridRange)
+			}
+
+			ruleType, condition, format := describeConditionalFormat(rule)
+			formatJSON, err := json.Marshal(format)
+			if err != nil {
+				return nil, err
+			}
+
+			sheetNames = append(sheetNames, sheet.Properties.Title)
+			ranges = append(ranges, strings.Join(a1, ","))
+			ruleTypes = append(ruleTypes, ruleType)
+			conditions = append(conditions, condition)
+			formats = append(formats, string(formatJSON))
+		}
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("sheet", nil, sheetNames),
+		data.NewField("ranges", nil, ranges),
+		data.NewField("type", nil, ruleTypes),
+		data.NewField("condition", nil, conditions),
+		data.NewField("format", nil, formats),
+	)
+	frame.RefID = refID
+	meta["spreadsheetId"] = qm.Spreadsheet
+	frame.Meta = &data.FrameMeta{Custom: meta}
+	return frame, nil
+}
+
+// getSheetList returns a frame with the title, id, index, size, visibility and tab color of every sheet in the spreadsheet.
+func (gs *GoogleSheets) getSheetList(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	metadataQuery := *qm
+	metadataQuery.Range = ""
+	spreadsheet, meta, err := gs.getSpreadsheetMetadata(ctx, client, &metadataQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := []string{}
+	ids := []int64{}
+	indexes := []int64{}
+	rowCounts := []int64{}
+	columnCounts := []int64{}
+	hidden := []bool{}
+	colors := []*string{}
+	var theme *sheets.SpreadsheetTheme
+	if spreadsheet.Properties != nil {
+		theme = spreadsheet.Properties.SpreadsheetTheme
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		properties := sheet.Properties
+		if properties == nil {
+			continue
+		}
+		var rowCount, columnCount int64
+		if properties.GridProperties != nil {
+			rowCount = properties.GridProperties.RowCount
+			columnCount = properties.GridProperties.ColumnCount
+		}
+
+		titles = append(titles, properties.Title)
+		ids = append(ids, properties.SheetId)
+		indexes = append(indexes, properties.Index)
+		rowCounts = append(rowCounts, rowCount)
+		columnCounts = append(columnCounts, columnCount)
+		hidden = append(hidden, properties.Hidden)
+		colors = append(colors, tabColorHex(properties, theme))
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("title", nil, titles),
+		data.NewField("sheetId", nil, ids),
+		data.NewField("index", nil, indexes),
+		data.NewField("rowCount", nil, rowCounts),
+		data.NewField("columnCount", nil, columnCounts),
+		data.NewField("hidden", nil, hidden),
+		data.NewField("color", nil, colors),
+	)
+	frame.RefID = refID
+	meta["spreadsheetId"] = qm.Spreadsheet
+	frame.Meta = &data.FrameMeta{Custom: meta}
+	return frame, nil
+}
+
+// tabColorHex returns the tab color of a sheet as a hex string like "#ff0000", or nil if the tab has no color.
+// Theme colors are resolved with the spreadsheet theme.
+func tabColorHex(properties *sheets.SheetProperties, theme *sheets.SpreadsheetTheme) *string {
+	color := properties.TabColor
+	if style := properties.TabColorStyle; style != nil {
+		if style.RgbColor != nil {
+			color = style.RgbColor
+		} else if style.ThemeColor != "" && theme != nil {
+			for _, pair := range theme.ThemeColors {
+				if pair.ColorType == style.ThemeColor && pair.Color != nil {
+					color = pair.Color.RgbColor
+				}
+			}
+		}
+	}
+	if color == nil {
+		return nil
+	}
+
+	hex := fmt.Sprintf("#%02x%02x%02x", colorByte(color.Red), colorByte(color.Green), colorByte(color.Blue))
+	return &hex
+}
+
+func colorByte(component float64) int {
+	return int(math.Round(component * 255))
+}
+
+// getCharts returns a frame with the sheet, id, title and type of every chart in the spreadsheet.
+func (gs *GoogleSheets) getCharts(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	metadataQuery := *qm
+	metadataQuery.Range = ""
+	spreadsheet, meta, err := gs.getSpreadsheetMetadata(ctx, client, &metadataQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetNames := []string{}
+	ids := []int64{}
+	titles := []string{}
+	chartTypes := []string{}
+	for _, sheet := range spreadsheet.Sheets {
+		for _, chart := range sheet.Charts {
+			title, chartType := "", ""
+			if chart.Spec != nil {
+				title = chart.Spec.Title
+				chartType = chartSpecType(chart.Spec)
+			}
+			sheetNames = append(sheetNames, sheet.Properties.Title)
+			ids = append(ids, chart.ChartId)
+			titles = append(titles, title)
+			chartTypes = append(chartTypes, chartType)
+		}
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("sheet", nil, sheetNames),
+		data.NewField("chartId", nil, ids),
+		data.NewField("title", nil, titles),
+		data.NewField("type", nil, chartTypes),
+	)
+	frame.RefID = refID
+	meta["spreadsheetId"] = qm.Spreadsheet
+	frame.Meta = &data.FrameMeta{Custom: meta}
+	return frame, nil
+}
+
+// chartSpecType returns the type of a chart, e.g. LINE or PIE. Basic charts have their own chart type.
+func chartSpecType(spec *sheets.ChartSpec) string {
+	switch {
+	case spec.BasicChart != nil:
+		return spec.BasicChart.ChartType
+	case spec.PieChart != nil:
+		return "PIE"
+	case spec.BubbleChart != nil:
+		return "BUBBLE"
+	case spec.CandlestickChart != nil:
+		return "CANDLESTICK"
+	case spec.HistogramChart != nil:
+		return "HISTOGRAM"
+	case spec.OrgChart != nil:
+		return "ORG"
+	case spec.ScorecardChart != nil:
+		return "SCORECARD"
+	case spec.TreemapChart != nil:
+		return "TREEMAP"
+	case spec.WaterfallChart != nil:
+		return "WATERFALL"
+	}
+	return ""
+}
+
+// getNamedRanges returns a frame with the name, A1 range and sheet of every named range in the spreadsheet.
+func (gs *GoogleSheets) getNamedRanges(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, error) {
+	metadataQuery := *qm
+	metadataQuery.Range = ""
+	spreadsheet, meta, err := gs.getSpreadsheetMetadata(ctx, client, &metadataQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := map[int64]string{}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil {
+			titles[sheet.Properties.SheetId] = sheet.Properties.Title
+		}
+	}
+
+	names := []string{}
+	ranges := []string{}
+	sheetNames := []string{}
+	for _, namedRange := range spreadsheet.NamedRanges {
+		if namedRange.Range == nil {
+			continue
+		}
+		title := titles[namedRange.Range.SheetId]
+		names = append(names, namedRange.Name)
+		ranges = append(ranges, gridRangeToA1(title, namedRange.Range))
+		sheetNames = append(sheetNames, title)
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("name", nil, names),
+		data.NewField("range", nil, ranges),
+		data.NewField("sheet", nil, sheetNames),
+	)
+	frame.RefID = refID
+	meta["spreadsheetId"] = qm.Spreadsheet
+	frame.Meta = &data.FrameMeta{Custom: meta}
+	return frame, nil
+}
+
+func describeConditionalFormat(rule *sheets.ConditionalFormatRule) (string, string, interface{}) {
+	if rule.BooleanRule != nil {
+		condition := ""
+		if rule.BooleanRule.Condition != nil {
+			values := []string{}
+			for _, value := range rule.BooleanRule.Condition.Values {
+				values = append(values, value.UserEnteredValue)
+			}
+			condition = strings.TrimSpace(rule.BooleanRule.Condition.Type + " " + strings.Join(values, ","))
+		}
+		return "BOOLEAN", condition, rule.BooleanRule.Format
+	}
+
+	if rule.GradientRule != nil {
+		points := []string{}
+		for _, point := range []*sheets.InterpolationPoint{rule.GradientRule.Minpoint, rule.GradientRule.Midpoint, rule.GradientRule.Maxpoint} {
+			if point != nil {
+				points = append(points, strings.TrimSpace(fmt.Sprintf("%s %s", point.Type, point.Value)))
+			}
+		}
+		return "GRADIENT", strings.Join(points, ","), rule.GradientRule
+	}
+
+	return "UNKNOWN", "", nil
+}
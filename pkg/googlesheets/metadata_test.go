@@ -0,0 +1,127 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("conditional formats", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/conditional-formats.json")
+		require.NoError(t, err)
+
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10, QueryType: models.QueryTypeConditionalFormats}
+
+		frame, err := gsd.getConditionalFormats(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 5, len(frame.Fields))
+		require.Equal(t, 2, frame.Fields[0].Len())
+
+		assert.Equal(t, "Sales", frame.Fields[0].At(0))
+		assert.Equal(t, "'Sales'!B2:B100", frame.Fields[1].At(0))
+		assert.Equal(t, "BOOLEAN", frame.Fields[2].At(0))
+		assert.Equal(t, "NUMBER_GREATER 100", frame.Fields[3].At(0))
+		assert.Contains(t, frame.Fields[4].At(0), `"bold":true`)
+
+		assert.Equal(t, "'Sales'!C:D", frame.Fields[1].At(1))
+		assert.Equal(t, "GRADIENT", frame.Fields[2].At(1))
+		assert.Equal(t, "MIN,NUMBER 500", frame.Fields[3].At(1))
+
+		t.Run("metadata is cached", func(t *testing.T) {
+			frame, err := gsd.getConditionalFormats(context.Background(), client, "ref1", &qm)
+			require.NoError(t, err)
+			assert.True(t, frame.Meta.Custom.(map[string]interface{})["hit"].(bool))
+			assert.Equal(t, 1, client.getSpreadsheetCalls)
+		})
+	})
+
+	t.Run("named ranges", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/named-ranges.json")
+		require.NoError(t, err)
+
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:B", CacheDurationSeconds: 10, QueryType: models.QueryTypeListNamedRanges}
+
+		frame, err := gsd.getNamedRanges(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "", client.requestedRanges[0])
+
+		assert.Equal(t, "Totals", frame.Fields[0].At(0))
+		assert.Equal(t, "'Summary'!A1:B3", frame.Fields[1].At(0))
+		assert.Equal(t, "Summary", frame.Fields[2].At(0))
+		assert.Equal(t, "Regions", frame.Fields[0].At(1))
+		assert.Equal(t, "'Sheet1'!A2:A3", frame.Fields[1].At(1))
+		assert.Equal(t, "'Sheet1'!B:B", frame.Fields[1].At(2))
+
+		_, err = gsd.getNamedRanges(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 1, client.getSpreadsheetCalls)
+	})
+
+	t.Run("sheets are listed with their tab color", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/tab-colors.json")
+		require.NoError(t, err)
+
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", CacheDurationSeconds: 10, QueryType: models.QueryTypeListSheets}
+
+		frame, err := gsd.getSheetList(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+
+		assert.Equal(t, "Plain", frame.Fields[0].At(1))
+		assert.Equal(t, int64(5), frame.Fields[1].At(1))
+		assert.Equal(t, true, frame.Fields[5].At(1))
+
+		field := frame.Fields[6]
+		assert.Equal(t, "color", field.Name)
+		assert.Equal(t, "#ff0000", *field.At(0).(*string))
+		assert.Nil(t, field.At(1))
+		assert.Equal(t, "#4285f4", *field.At(2).(*string), "theme colors are resolved")
+	})
+
+	t.Run("charts", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/charts.json")
+		require.NoError(t, err)
+
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:B", CacheDurationSeconds: 10, QueryType: models.QueryTypeListCharts}
+
+		frame, err := gsd.getCharts(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "", client.requestedRanges[0])
+
+		assert.Equal(t, "Sales", frame.Fields[0].At(0))
+		assert.Equal(t, int64(1138), frame.Fields[1].At(0))
+		assert.Equal(t, "Sales per month", frame.Fields[2].At(0))
+		assert.Equal(t, "LINE", frame.Fields[3].At(0))
+		assert.Equal(t, "Regions", frame.Fields[0].At(1))
+		assert.Equal(t, "PIE", frame.Fields[3].At(1))
+
+		_, err = gsd.getCharts(context.Background(), client, "ref1", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 1, client.getSpreadsheetCalls)
+	})
+}
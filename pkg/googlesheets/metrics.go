@@ -0,0 +1,164 @@
+package googlesheets
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Metrics counts queries, Sheets API calls and cache lookups. A nil *Metrics records nothing.
+type Metrics struct {
+	queries      *prometheus.CounterVec
+	apiRequests  *prometheus.CounterVec
+	apiErrors    *prometheus.CounterVec
+	apiDuration  *prometheus.HistogramVec
+	cacheLookups *prometheus.CounterVec
+}
+
+// NewMetrics creates the datasource metrics in the given namespace.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "data_query_total",
+			Help:      "data query counter",
+		}, []string{"scenario"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_requests_total",
+			Help:      "Sheets API request counter",
+		}, []string{"method"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_errors_total",
+			Help:      "Sheets API error counter by HTTP status code",
+		}, []string{"method", "code"}),
+		apiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Sheets API request latency",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "sheet data cache lookups by result",
+		}, []string{"result"}),
+	}
+}
+
+// MustRegister registers all metrics, panicking if any of them is already registered.
+func (m *Metrics) MustRegister(registerer prometheus.Registerer) {
+	registerer.MustRegister(m.queries, m.apiRequests, m.apiErrors, m.apiDuration, m.cacheLookups)
+}
+
+func (m *Metrics) observeQuery(queryType string) {
+	if m == nil {
+		return
+	}
+	if queryType == "" {
+		queryType = "data"
+	}
+	m.queries.WithLabelValues(queryType).Inc()
+}
+
+func (m *Metrics) observeCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+// observeAPIRequest records an API call that started at start and returned err.
+func (m *Metrics) observeAPIRequest(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.apiRequests.WithLabelValues(method).Inc()
+	m.apiDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.apiErrors.WithLabelValues(method, errorCode(err)).Inc()
+	}
+}
+
+// errorCode returns the HTTP status code of an API error, or "unknown" for other errors such as timeouts.
+func errorCode(err error) string {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return strconv.Itoa(apiErr.Code)
+	}
+	return "unknown"
+}
+
+// meteredClient records every API call of the client it wraps.
+type meteredClient struct {
+	client
+	metrics *Metrics
+}
+
+// withMetrics wraps the client so its API calls are recorded, if metrics are wired.
+func (gs *GoogleSheets) withMetrics(c client) client {
+	if gs.Metrics == nil {
+		return c
+	}
+	return &meteredClient{client: c, metrics: gs.Metrics}
+}
+
+func (c *meteredClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
+	start := time.Now()
+	result, err := c.client.GetSpreadsheet(ctx, spreadSheetID, sheetRange, includeGridData, fields)
+	c.metrics.observeAPIRequest("getSpreadsheet", start, err)
+	return result, err
+}
+
+func (c *meteredClient) GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error) {
+	start := time.Now()
+	result, err := c.client.GetSpreadsheetRanges(ctx, spreadSheetID, sheetRanges, fields)
+	c.metrics.observeAPIRequest("getSpreadsheetRanges", start, err)
+	return result, err
+}
+
+func (c *meteredClient) GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error) {
+	start := time.Now()
+	count, err := c.client.GetRowCount(ctx, spreadSheetID, sheetRange)
+	c.metrics.observeAPIRequest("getRowCount", start, err)
+	return count, err
+}
+
+func (c *meteredClient) GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error) {
+	start := time.Now()
+	files, err := c.client.GetSpreadsheetFiles(ctx)
+	c.metrics.observeAPIRequest("getSpreadsheetFiles", start, err)
+	return files, err
+}
+
+func (c *meteredClient) BatchUpdate(ctx context.Context, spreadSheetID string, request *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	start := time.Now()
+	response, err := c.client.BatchUpdate(ctx, spreadSheetID, request)
+	c.metrics.observeAPIRequest("batchUpdate", start, err)
+	return response, err
+}
+
+func (c *meteredClient) GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error) {
+	start := time.Now()
+	revisionID, err := c.client.GetLatestRevisionID(ctx, spreadSheetID)
+	c.metrics.observeAPIRequest("getLatestRevisionID", start, err)
+	return revisionID, err
+}
+
+func (c *meteredClient) GetVersion(ctx context.Context, spreadSheetID string) (int64, error) {
+	start := time.Now()
+	version, err := c.client.GetVersion(ctx, spreadSheetID)
+	c.metrics.observeAPIRequest("getVersion", start, err)
+	return version, err
+}
@@ -0,0 +1,86 @@
+package googlesheets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("counters move on fetch and cache hit", func(t *testing.T) {
+		metrics := NewMetrics("test")
+		metrics.MustRegister(prometheus.NewRegistry())
+		gsd := &GoogleSheets{
+			Cache:   cache.New(300*time.Second, 50*time.Second),
+			Metrics: metrics,
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O", CacheDurationSeconds: 10}
+
+		_, _, err := gsd.getSheetData(context.Background(), gsd.withMetrics(&fakeClient{}), &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiRequests.WithLabelValues("getSpreadsheet")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues("miss")))
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues("hit")))
+
+		_, _, err = gsd.getSheetData(context.Background(), gsd.withMetrics(&fakeClient{}), &qm)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiRequests.WithLabelValues("getSpreadsheet")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues("hit")))
+	})
+
+	t.Run("errors are counted by status code", func(t *testing.T) {
+		metrics := NewMetrics("test")
+		gsd := &GoogleSheets{
+			Cache:   cache.New(300*time.Second, 50*time.Second),
+			Metrics: metrics,
+		}
+		client := &fakeClient{getSpreadsheetErr: &googleapi.Error{Code: http.StatusForbidden}}
+
+		_, _, err := gsd.getSheetData(context.Background(), gsd.withMetrics(client), &models.QueryModel{Spreadsheet: "someid"})
+		require.Error(t, err)
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiErrors.WithLabelValues("getSpreadsheet", "403")))
+	})
+
+	t.Run("other client methods are recorded", func(t *testing.T) {
+		metrics := NewMetrics("test")
+		gsd := &GoogleSheets{Metrics: metrics}
+		client := gsd.withMetrics(&fakeClient{filesErr: &googleapi.Error{Code: http.StatusTooManyRequests}})
+
+		_, err := client.GetRowCount(context.Background(), "someid", "Sheet1")
+		require.NoError(t, err)
+		_, err = client.BatchUpdate(context.Background(), "someid", &sheets.BatchUpdateSpreadsheetRequest{})
+		require.NoError(t, err)
+		_, err = client.GetSpreadsheetFiles(context.Background())
+		require.Error(t, err)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiRequests.WithLabelValues("getRowCount")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiRequests.WithLabelValues("batchUpdate")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiErrors.WithLabelValues("getSpreadsheetFiles", "429")))
+	})
+
+	t.Run("queries are counted by scenario", func(t *testing.T) {
+		metrics := NewMetrics("test")
+		gsd := &GoogleSheets{Metrics: metrics}
+		gsd.Query(context.Background(), "A", &models.QueryModel{Sample: models.Sample{EveryNth: -1}}, &models.DatasourceSettings{}, backend.TimeRange{})
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.With(prometheus.Labels{"scenario": "data"})))
+	})
+
+	t.Run("nothing is recorded without metrics", func(t *testing.T) {
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		_, _, err := gsd.getSheetData(context.Background(), &fakeClient{}, &models.QueryModel{Spreadsheet: "someid"})
+		assert.NoError(t, err)
+	})
+}
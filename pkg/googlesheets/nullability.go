@@ -0,0 +1,60 @@
+package googlesheets
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// emptyTime is the value of empty time cells in fields that are not nullable.
+var emptyTime = time.Unix(0, 0).UTC()
+
+// concreteFields replaces the nullable number, time and bool fields with non-nullable ones, for transforms that
+// treat nullable fields differently. Empty numbers become emptyValue, empty times the Unix epoch and empty
+// bools false.
+func concreteFields(frame *data.Frame, emptyValue float64) {
+	for i, field := range frame.Fields {
+		var concrete *data.Field
+		switch field.Type() {
+		case data.FieldTypeNullableFloat64:
+			values := make([]float64, field.Len())
+			for row := range values {
+				values[row] = emptyValue
+				if value, ok := field.ConcreteAt(row); ok {
+					values[row] = value.(float64)
+				}
+			}
+			concrete = data.NewField(field.Name, field.Labels, values)
+		case data.FieldTypeNullableInt64:
+			values := make([]int64, field.Len())
+			for row := range values {
+				values[row] = int64(emptyValue)
+				if value, ok := field.ConcreteAt(row); ok {
+					values[row] = value.(int64)
+				}
+			}
+			concrete = data.NewField(field.Name, field.Labels, values)
+		case data.FieldTypeNullableTime:
+			values := make([]time.Time, field.Len())
+			for row := range values {
+				values[row] = emptyTime
+				if value, ok := field.ConcreteAt(row); ok {
+					values[row] = value.(time.Time)
+				}
+			}
+			concrete = data.NewField(field.Name, field.Labels, values)
+		case data.FieldTypeNullableBool:
+			values := make([]bool, field.Len())
+			for row := range values {
+				if value, ok := field.ConcreteAt(row); ok {
+					values[row] = value.(bool)
+				}
+			}
+			concrete = data.NewField(field.Name, field.Labels, values)
+		default:
+			continue
+		}
+		concrete.Config = field.Config
+		frame.Fields[i] = concrete
+	}
+}
@@ -0,0 +1,64 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullableFields(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/expenses.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("number fields are nullable by default", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		amount := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, amount.Type())
+		assert.Equal(t, 12.5, *amount.At(0).(*float64))
+		assert.Nil(t, amount.At(4))
+	})
+
+	t.Run("empty numbers are the empty value in non-nullable fields", func(t *testing.T) {
+		nullable := false
+		qm := models.QueryModel{Spreadsheet: "someid", NullableFields: &nullable, EmptyValue: -1}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		amount := frame.Fields[1]
+		require.Equal(t, data.FieldTypeFloat64, amount.Type())
+		assert.Equal(t, 12.5, amount.At(0))
+		assert.Equal(t, -1.0, amount.At(4))
+		assert.Equal(t, "Amount", amount.Config.DisplayName)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type(), "strings stay nullable")
+	})
+
+	t.Run("empty times are the Unix epoch in non-nullable fields", func(t *testing.T) {
+		timeValue := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+		frame := data.NewFrame("", data.NewField("Date", nil, []*time.Time{&timeValue, nil}))
+		concreteFields(frame, 0)
+
+		require.Equal(t, data.FieldTypeTime, frame.Fields[0].Type())
+		assert.Equal(t, timeValue, frame.Fields[0].At(0))
+		assert.Equal(t, time.Unix(0, 0).UTC(), frame.Fields[0].At(1))
+	})
+
+	t.Run("empty bools are false in non-nullable fields", func(t *testing.T) {
+		done := true
+		frame := data.NewFrame("", data.NewField("Done", nil, []*bool{&done, nil}))
+		concreteFields(frame, 0)
+
+		require.Equal(t, data.FieldTypeBool, frame.Fields[0].Type())
+		assert.Equal(t, true, frame.Fields[0].At(0))
+		assert.Equal(t, false, frame.Fields[0].At(1))
+	})
+}
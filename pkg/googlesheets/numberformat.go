@@ -0,0 +1,57 @@
+package googlesheets
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// patternDecimals counts the digit placeholders after the decimal point of a number format pattern, e.g. 2 for "#,##0.00".
+// Formats without a pattern use the locale default, so they have no decimals of their own.
+func patternDecimals(format *sheets.NumberFormat) (uint16, bool) {
+	if format == nil || format.Pattern == "" {
+		return 0, false
+	}
+	switch format.Type {
+	case "NUMBER", "PERCENT", "CURRENCY", "SCIENTIFIC":
+	default:
+		return 0, false
+	}
+
+	// Only the first section of a pattern like "0.00;(0.00)" applies to positive numbers
+	pattern := strings.SplitN(format.Pattern, ";", 2)[0]
+	point := strings.Index(pattern, ".")
+	if point < 0 {
+		return 0, true
+	}
+
+	var decimals uint16
+	for _, r := range pattern[point+1:] {
+		if r != '0' && r != '#' && r != '?' {
+			break
+		}
+		decimals++
+	}
+	return decimals, true
+}
+
+// applyDefaultFormat sets the decimals of the spreadsheet default number format on the number fields
+// whose column has no number format of its own.
+func applyDefaultFormat(frame *data.Frame, defaultFormat *sheets.CellFormat) {
+	if defaultFormat == nil {
+		return
+	}
+	decimals, ok := patternDecimals(defaultFormat.NumberFormat)
+	if !ok {
+		return
+	}
+
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableFloat64 || field.Config == nil || field.Config.Decimals != nil {
+			continue
+		}
+		d := decimals
+		field.Config.Decimals = &d
+	}
+}
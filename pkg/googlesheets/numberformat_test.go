@@ -0,0 +1,101 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestDefaultFormat(t *testing.T) {
+	t.Run("decimals are read from number format patterns", func(t *testing.T) {
+		tests := map[string]uint16{"#,##0.00": 2, "0.0%": 1, "0": 0, "[$€]#,##0.000;(#,##0.000)": 3, "0.00E+00": 2}
+		for pattern, expected := range tests {
+			decimals, ok := patternDecimals(&sheets.NumberFormat{Type: "NUMBER", Pattern: pattern})
+			require.True(t, ok, pattern)
+			assert.Equal(t, expected, decimals, pattern)
+		}
+
+		_, ok := patternDecimals(&sheets.NumberFormat{Type: "NUMBER"})
+		assert.False(t, ok)
+		_, ok = patternDecimals(&sheets.NumberFormat{Type: "DATE", Pattern: "yyyy-mm-dd"})
+		assert.False(t, ok)
+	})
+
+	t.Run("spreadsheet default format sets decimals of unformatted columns", func(t *testing.T) {
+		spreadsheet, err := loadTestSheet("./testdata/default-format.json")
+		require.NoError(t, err)
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		frame, err := gsd.querySheet(context.Background(), client, "A", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+
+		require.Equal(t, 3, len(frame.Fields))
+		assert.Nil(t, frame.Fields[0].Config.Decimals)
+		require.NotNil(t, frame.Fields[1].Config.Decimals)
+		assert.Equal(t, uint16(1), *frame.Fields[1].Config.Decimals)
+		require.NotNil(t, frame.Fields[2].Config.Decimals)
+		assert.Equal(t, uint16(2), *frame.Fields[2].Config.Decimals)
+	})
+}
+
+func TestComputeFieldRanges(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/orders.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("number fields get their observed min and max", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ComputeFieldRanges: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		price := frame.Fields[1].Config
+		require.NotNil(t, price.Min)
+		assert.Equal(t, 0.25, float64(*price.Min))
+		assert.Equal(t, 2.0, float64(*price.Max))
+
+		quantity := frame.Fields[2].Config
+		assert.Equal(t, 4.0, float64(*quantity.Min), "null values are ignored")
+		assert.Equal(t, 10.0, float64(*quantity.Max))
+
+		assert.Nil(t, frame.Fields[0].Config.Min, "string fields have no range")
+	})
+
+	t.Run("ranges are not computed by default", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Nil(t, frame.Fields[1].Config.Min)
+		assert.Nil(t, frame.Fields[1].Config.Max)
+	})
+}
+
+func TestTimeOfDay(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/time-of-day.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+	require.NoError(t, err)
+
+	start := frame.Fields[1]
+	require.Equal(t, data.FieldTypeNullableFloat64, start.Type(), "times of day are not dates")
+	assert.Equal(t, "clocks", start.Config.Unit)
+	assert.Equal(t, 6*60*60.0, *start.At(0).(*float64))
+	assert.Equal(t, 14*60*60+30*60.0, *start.At(1).(*float64))
+	assert.Equal(t, 23*60*60+59*60.0, *start.At(2).(*float64))
+
+	assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[2].Type(), "dates are still times")
+}
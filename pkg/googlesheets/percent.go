@@ -0,0 +1,88 @@
+package googlesheets
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// textPercentPattern matches strings like "25%", "-1.5 %" or "+.5%".
+var textPercentPattern = regexp.MustCompile(`^\s*([-+]?(?:\d+\.?\d*|\.\d+))\s*%\s*$`)
+
+// parseTextPercent returns the number of a string cell holding a percentage, e.g. 25 for "25%".
+func parseTextPercent(cell *sheets.CellData) (float64, bool) {
+	if cell == nil || cell.EffectiveValue == nil || cell.EffectiveValue.StringValue == nil {
+		return 0, false
+	}
+	match := textPercentPattern.FindStringSubmatch(*cell.EffectiveValue.StringValue)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	return number, err == nil
+}
+
+// convertTextPercentages returns copies of rows where the columns holding only text percentages have percent
+// formatted number cells instead, storing 0.25 rather than 25 for "25%" when asFraction is set. Columns mixing
+// text percentages with other values are forced to strings with a warning. The converted columns are returned.
+func convertTextPercentages(rows []*sheets.RowData, columns []*ColumnDefinition, rowNumbers map[*sheets.RowData]int, asFraction bool) ([]*sheets.RowData, map[*ColumnDefinition]bool, []string) {
+	converted := map[*ColumnDefinition]bool{}
+	warnings := []string{}
+	for _, column := range columns {
+		percents, others := 0, 0
+		for _, row := range rows {
+			if column.ColumnIndex >= len(row.Values) {
+				continue
+			}
+			cell := row.Values[column.ColumnIndex]
+			if cell == nil || cell.FormattedValue == "" {
+				continue
+			}
+			if _, ok := parseTextPercent(cell); ok {
+				percents++
+			} else {
+				others++
+			}
+		}
+
+		switch {
+		case percents > 0 && others == 0:
+			converted[column] = true
+		case percents > 0:
+			column.ForceType(ColumTypeString)
+			warnings = append(warnings, fmt.Sprintf("Column %q mixes percentages with other values. Using string data type", column.Header))
+		}
+	}
+	if len(converted) == 0 {
+		return rows, converted, warnings
+	}
+
+	percentFormat := &sheets.CellFormat{NumberFormat: &sheets.NumberFormat{Type: "PERCENT"}}
+	mapped := make([]*sheets.RowData, len(rows))
+	for i, row := range rows {
+		values := make([]*sheets.CellData, len(row.Values))
+		copy(values, row.Values)
+		for column := range converted {
+			if column.ColumnIndex >= len(values) {
+				continue
+			}
+			number, ok := parseTextPercent(values[column.ColumnIndex])
+			if !ok {
+				continue
+			}
+			if asFraction {
+				number /= 100
+			}
+			cell := *values[column.ColumnIndex]
+			cell.EffectiveValue = &sheets.ExtendedValue{NumberValue: &number}
+			cell.EffectiveFormat = percentFormat
+			cell.UserEnteredFormat = percentFormat
+			values[column.ColumnIndex] = &cell
+		}
+		mapped[i] = &sheets.RowData{Values: values}
+		rowNumbers[mapped[i]] = rowNumbers[row]
+	}
+	return mapped, converted, warnings
+}
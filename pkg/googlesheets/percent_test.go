@@ -0,0 +1,70 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextPercentages(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/text-percentages.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("text percentages are numbers with a percent unit", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TextPercentages: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		require.Equal(t, data.FieldTypeNullableFloat64, field.Type())
+		assert.Equal(t, "percent", field.Config.Unit)
+		assert.Equal(t, 25.0, *field.At(0).(*float64))
+		assert.Equal(t, -1.5, *field.At(1).(*float64))
+		assert.Nil(t, field.At(2))
+		assert.Equal(t, 110.0, *field.At(3).(*float64))
+
+		assert.Equal(t, "25%", sheet.Sheets[0].Data[0].RowData[1].Values[1].FormattedValue)
+		assert.Nil(t, sheet.Sheets[0].Data[0].RowData[1].Values[1].EffectiveValue.NumberValue)
+	})
+
+	t.Run("text percentages can be stored as fractions", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TextPercentages: true, PercentAsFraction: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[1]
+		assert.Equal(t, "percentunit", field.Config.Unit)
+		assert.Equal(t, 0.25, *field.At(0).(*float64))
+		assert.Equal(t, 1.1, *field.At(3).(*float64))
+	})
+
+	t.Run("columns mixing percentages with other values stay strings", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TextPercentages: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[2]
+		require.Equal(t, data.FieldTypeNullableString, field.Type())
+		assert.Equal(t, "12%", *field.At(0).(*string))
+		assert.Equal(t, "flat", *field.At(1).(*string))
+		assert.Equal(t, []string{`Column "Note" mixes percentages with other values. Using string data type`}, meta["warnings"])
+	})
+
+	t.Run("text percentages are strings by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+	})
+}
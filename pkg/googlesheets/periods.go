@@ -0,0 +1,85 @@
+package googlesheets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// periodFormats format a time as the name of the period it falls in.
+var periodFormats = map[string]func(t time.Time) string{
+	"isoweek": func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	"month": func(t time.Time) string {
+		return t.Format("2006-01")
+	},
+	"quarter": func(t time.Time) string {
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3)
+	},
+	"year": func(t time.Time) string {
+		return t.Format("2006")
+	},
+}
+
+func validateDerivePeriods(qm *models.QueryModel) error {
+	if len(qm.DerivePeriods) == 0 {
+		return nil
+	}
+	if qm.TimeColumn == "" {
+		return fmt.Errorf("derived periods require a time column")
+	}
+	for _, period := range qm.DerivePeriods {
+		if _, ok := periodFormats[period]; !ok {
+			return fmt.Errorf("unknown period %q, expected isoweek, month, quarter or year", period)
+		}
+	}
+	return nil
+}
+
+// addPeriodFields appends a string field per period, e.g. "Date_month" with values like "2021-04", holding the
+// period the time of the time column falls in. Times emitted as epoch milliseconds are supported too, they are
+// taken in loc, the time zone of the spreadsheet, or UTC when nil.
+func addPeriodFields(frame *data.Frame, timeColumn string, periods []string, loc *time.Location) error {
+	index := frameFieldIndex(frame, timeColumn)
+	if index < 0 {
+		return fmt.Errorf("time column %q not found", timeColumn)
+	}
+	source := frame.Fields[index]
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, period := range periods {
+		format := periodFormats[period]
+		values := make([]*string, source.Len())
+		for i := range values {
+			var t time.Time
+			switch value := source.At(i).(type) {
+			case *time.Time:
+				if value == nil {
+					continue
+				}
+				t = *value
+			case *int64:
+				if value == nil {
+					continue
+				}
+				t = time.Unix(0, *value*int64(time.Millisecond)).In(loc)
+			default:
+				return fmt.Errorf("time column %q is not a time column", timeColumn)
+			}
+			name := format(t)
+			values[i] = &name
+		}
+
+		name := timeColumn + "_" + period
+		field := data.NewField(name, nil, values)
+		field.Config = &data.FieldConfig{DisplayName: name}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return nil
+}
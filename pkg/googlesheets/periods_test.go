@@ -0,0 +1,67 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivePeriods(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/report-dates.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("month and ISO week fields are derived from the time column", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeColumn: "Date", DerivePeriods: []string{"month", "isoweek"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		month := frame.Fields[frameFieldIndex(frame, "Date_month")]
+		week := frame.Fields[frameFieldIndex(frame, "Date_isoweek")]
+		assert.Equal(t, "2021-01", *month.At(0).(*string))
+		assert.Equal(t, "2020-W53", *week.At(0).(*string), "early January can be in the last week of the year before")
+		assert.Equal(t, "2021-W01", *week.At(1).(*string))
+		assert.Equal(t, "2021-04", *month.At(2).(*string))
+		assert.Equal(t, "2021-W15", *week.At(2).(*string))
+		assert.Nil(t, month.At(3), "rows without a time have no period")
+		assert.Equal(t, "2021-W52", *week.At(4).(*string))
+	})
+
+	t.Run("quarters and years of epoch milliseconds", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeColumn: "Date", TimeAsEpochMillis: true, DerivePeriods: []string{"quarter", "year"}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		quarter := frame.Fields[frameFieldIndex(frame, "Date_quarter")]
+		assert.Equal(t, "2021-Q1", *quarter.At(1).(*string))
+		assert.Equal(t, "2021-Q2", *quarter.At(2).(*string))
+		assert.Equal(t, "2021-Q4", *quarter.At(4).(*string))
+		assert.Equal(t, "2021", *frame.Fields[frameFieldIndex(frame, "Date_year")].At(4).(*string))
+	})
+
+	t.Run("epoch milliseconds are in the time zone of the spreadsheet", func(t *testing.T) {
+		tokyo := time.FixedZone("JST", 9*60*60)
+		millis := time.Date(2021, 4, 1, 3, 0, 0, 0, tokyo).UnixNano() / int64(time.Millisecond)
+		frame := data.NewFrame("", data.NewField("Date", nil, []*int64{&millis}))
+		require.NoError(t, addPeriodFields(frame, "Date", []string{"month", "quarter"}, tokyo))
+
+		assert.Equal(t, "2021-04", *frame.Fields[1].At(0).(*string), "it is still March in UTC")
+		assert.Equal(t, "2021-Q2", *frame.Fields[2].At(0).(*string))
+
+		frame = data.NewFrame("", data.NewField("Date", nil, []*int64{&millis}))
+		require.NoError(t, addPeriodFields(frame, "Date", []string{"month"}, nil))
+		assert.Equal(t, "2021-03", *frame.Fields[1].At(0).(*string), "UTC without a time zone")
+	})
+
+	t.Run("invalid options are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{DerivePeriods: []string{"month"}}))
+		assert.Error(t, validateQuery(&models.QueryModel{TimeColumn: "Date", DerivePeriods: []string{"fortnight"}}))
+	})
+}
@@ -0,0 +1,134 @@
+package googlesheets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func validatePivot(qm *models.QueryModel) error {
+	if qm.PivotColumn == "" && qm.PivotValueColumn == "" {
+		return nil
+	}
+	if qm.PivotColumn == "" || qm.PivotValueColumn == "" {
+		return fmt.Errorf("pivot requires both a pivot column and a pivot value column")
+	}
+	if qm.ExtractImages {
+		return fmt.Errorf("pivot can not be combined with image extraction")
+	}
+	return nil
+}
+
+// frameFieldIndex returns the index of the field with the given name, or -1 if there is none.
+func frameFieldIndex(frame *data.Frame, name string) int {
+	for i, field := range frame.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatFieldValue formats a nullable field value, returning false for null.
+func formatFieldValue(field *data.Field, row int) (string, bool) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// pivotFrame turns the distinct values of the pivot column into fields holding the pivot value column,
+// with one row per distinct combination of the index columns. Missing combinations are null.
+func pivotFrame(frame *data.Frame, indexColumns []string, pivotColumn string, valueColumn string) (*data.Frame, []string, error) {
+	pivotIndex := frameFieldIndex(frame, pivotColumn)
+	if pivotIndex < 0 {
+		return nil, nil, fmt.Errorf("pivot column %q not found", pivotColumn)
+	}
+	valueIndex := frameFieldIndex(frame, valueColumn)
+	if valueIndex < 0 {
+		return nil, nil, fmt.Errorf("pivot value column %q not found", valueColumn)
+	}
+	indexes := make([]int, len(indexColumns))
+	for i, column := range indexColumns {
+		indexes[i] = frameFieldIndex(frame, column)
+		if indexes[i] < 0 {
+			return nil, nil, fmt.Errorf("index column %q not found", column)
+		}
+	}
+
+	// Find the output rows and fields in the order they first appear
+	keyRows := []int{}
+	rowByKey := map[string]int{}
+	pivotNames := []string{}
+	fieldByName := map[string]int{}
+	rowKeys := make([]int, frame.Rows())
+	rowPivots := make([]string, frame.Rows())
+	for row := 0; row < frame.Rows(); row++ {
+		name, ok := formatFieldValue(frame.Fields[pivotIndex], row)
+		if !ok {
+			rowKeys[row] = -1
+			continue
+		}
+		if _, exists := fieldByName[name]; !exists {
+			fieldByName[name] = len(pivotNames)
+			pivotNames = append(pivotNames, name)
+		}
+		rowPivots[row] = name
+
+		parts := make([]string, len(indexes))
+		for i, index := range indexes {
+			parts[i], _ = formatFieldValue(frame.Fields[index], row)
+		}
+		key := strings.Join(parts, "\x00")
+		if _, exists := rowByKey[key]; !exists {
+			rowByKey[key] = len(keyRows)
+			keyRows = append(keyRows, row)
+		}
+		rowKeys[row] = rowByKey[key]
+	}
+
+	pivoted := data.NewFrame(frame.Name)
+	pivoted.RefID = frame.RefID
+	for _, index := range indexes {
+		source := frame.Fields[index]
+		field := data.NewFieldFromFieldType(source.Type(), len(keyRows))
+		field.Name = source.Name
+		field.Config = source.Config
+		for i, row := range keyRows {
+			field.Set(i, source.CopyAt(row))
+		}
+		pivoted.Fields = append(pivoted.Fields, field)
+	}
+
+	valueField := frame.Fields[valueIndex]
+	valueFields := make([]*data.Field, len(pivotNames))
+	for i, name := range pivotNames {
+		field := data.NewFieldFromFieldType(valueField.Type(), len(keyRows))
+		field.Name = name
+		field.Config = &data.FieldConfig{DisplayName: name}
+		if valueField.Config != nil {
+			field.Config.Unit = valueField.Config.Unit
+		}
+		valueFields[i] = field
+		pivoted.Fields = append(pivoted.Fields, field)
+	}
+
+	warnings := []string{}
+	filled := map[[2]int]bool{}
+	for row := 0; row < frame.Rows(); row++ {
+		if rowKeys[row] < 0 {
+			continue
+		}
+		cell := [2]int{rowKeys[row], fieldByName[rowPivots[row]]}
+		if filled[cell] {
+			warnings = append(warnings, fmt.Sprintf("Multiple values found for %q in pivoted row %d. Using the last value", rowPivots[row], rowKeys[row]+1))
+		}
+		filled[cell] = true
+		valueFields[cell[1]].Set(cell[0], valueField.CopyAt(row))
+	}
+
+	return pivoted, warnings, nil
+}
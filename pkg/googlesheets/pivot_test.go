@@ -0,0 +1,62 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPivot(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/categories.json")
+	require.NoError(t, err)
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("distinct pivot values become fields", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", PivotColumn: "Category", PivotValueColumn: "Value", PivotIndexColumns: []string{"Day"}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 3)
+		assert.Equal(t, "Day", frame.Fields[0].Name)
+		assert.Equal(t, "A", frame.Fields[1].Name)
+		assert.Equal(t, "B", frame.Fields[2].Name)
+		require.Equal(t, 3, frame.Rows())
+
+		days := []string{"Mon", "Tue", "Wed"}
+		a := []interface{}{1.0, 3.0, nil}
+		b := []interface{}{2.0, nil, 4.0}
+		for row := range days {
+			assert.Equal(t, days[row], *frame.Fields[0].At(row).(*string))
+			for i, expected := range [][]interface{}{a, b} {
+				value := frame.Fields[i+1].At(row).(*float64)
+				if expected[row] == nil {
+					assert.Nil(t, value)
+				} else {
+					require.NotNil(t, value)
+					assert.Equal(t, expected[row], *value)
+				}
+			}
+		}
+		assert.Equal(t, "ref1", frame.RefID)
+		assert.Equal(t, "B", frame.Fields[2].Config.DisplayName)
+	})
+
+	t.Run("missing columns are an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", PivotColumn: "Type", PivotValueColumn: "Value"}
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		assert.Error(t, err)
+	})
+
+	t.Run("pivot value column is required", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{PivotColumn: "Category"}))
+	})
+}
@@ -0,0 +1,58 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"golang.org/x/oauth2"
+	googletransport "google.golang.org/api/googleapi/transport"
+	"google.golang.org/api/option"
+)
+
+// newTransport returns a transport that sends requests through the configured proxy and
+// presents the configured client certificate, or nil when neither is configured and the defaults apply.
+func newTransport(auth *models.DatasourceSettings) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(auth)
+	if err != nil {
+		return nil, err
+	}
+	if len(auth.ProxyURL) == 0 && tlsConfig == nil {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(auth.ProxyURL) > 0 {
+		proxyURL, err := url.Parse(auth.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", auth.ProxyURL)
+		}
+		if len(auth.ProxyUsername) > 0 {
+			proxyURL.User = url.UserPassword(auth.ProxyUsername, auth.ProxyPassword)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// apiKeyOption authenticates with the API key, through the custom transport if there is one.
+func apiKeyOption(apiKey string, transport *http.Transport) option.ClientOption {
+	if transport == nil {
+		return option.WithAPIKey(apiKey)
+	}
+	// The API key option is ignored when a custom HTTP client is used
+	return option.WithHTTPClient(&http.Client{Transport: &googletransport.APIKey{Key: apiKey, Transport: transport}})
+}
+
+// proxyContext makes the OAuth2 clients created with the context use the custom transport if there is one.
+func proxyContext(ctx context.Context, transport *http.Transport) context.Context {
+	if transport == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+}
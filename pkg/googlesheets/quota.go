@@ -0,0 +1,27 @@
+package googlesheets
+
+import (
+	"net/http"
+	"strings"
+)
+
+// quotaHeaderPrefixes are the response headers that carry rate limit and quota usage, when the API sends them.
+var quotaHeaderPrefixes = []string{"X-Ratelimit-", "X-Goog-Quota-", "Retry-After"}
+
+// quotaInfo returns the quota related response headers by their lowercase name, or nil if there are none.
+func quotaInfo(header http.Header) map[string]string {
+	var quota map[string]string
+	for name, values := range header {
+		canonical := http.CanonicalHeaderKey(name)
+		for _, prefix := range quotaHeaderPrefixes {
+			if strings.HasPrefix(canonical, prefix) && len(values) > 0 {
+				if quota == nil {
+					quota = map[string]string{}
+				}
+				quota[strings.ToLower(canonical)] = values[0]
+				break
+			}
+		}
+	}
+	return quota
+}
@@ -0,0 +1,144 @@
+package googlesheets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// rateLimiter is a token bucket refilled at a fixed number of requests per minute. It holds at most
+// one second worth of tokens, so bursts can not push a minute much over the rate.
+type rateLimiter struct {
+	requestsPerMinute int
+	interval          time.Duration
+	capacity          float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	capacity := float64(requestsPerMinute / 60)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &rateLimiter{
+		requestsPerMinute: requestsPerMinute,
+		interval:          time.Minute / time.Duration(requestsPerMinute),
+		capacity:          capacity,
+		tokens:            capacity,
+		last:              time.Now(),
+	}
+}
+
+// wait takes a token, waiting until one is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	// Take the token right away, waiting for it to be refilled if the bucket is empty
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the token back, nobody is going to use it
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// rateLimitedClient waits for the rate limiter before every request of the client.
+type rateLimitedClient struct {
+	client
+	limiter *rateLimiter
+}
+
+func (c *rateLimitedClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetSpreadsheet(ctx, spreadSheetID, sheetRange, includeGridData, fields)
+}
+
+func (c *rateLimitedClient) GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetSpreadsheetRanges(ctx, spreadSheetID, sheetRanges, fields)
+}
+
+func (c *rateLimitedClient) GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return 0, err
+	}
+	return c.client.GetRowCount(ctx, spreadSheetID, sheetRange)
+}
+
+func (c *rateLimitedClient) GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetSpreadsheetFiles(ctx)
+}
+
+func (c *rateLimitedClient) BatchUpdate(ctx context.Context, spreadSheetID string, request *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.BatchUpdate(ctx, spreadSheetID, request)
+}
+
+func (c *rateLimitedClient) GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return "", err
+	}
+	return c.client.GetLatestRevisionID(ctx, spreadSheetID)
+}
+
+func (c *rateLimitedClient) GetVersion(ctx context.Context, spreadSheetID string) (int64, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return 0, err
+	}
+	return c.client.GetVersion(ctx, spreadSheetID)
+}
+
+// withRateLimit wraps the client with the rate limiter of its datasource when RequestsPerMinute is set.
+// Limiters are shared by all queries of a datasource and replaced when its rate changes.
+func (gs *GoogleSheets) withRateLimit(c client, config *models.DatasourceSettings) client {
+	if config.RequestsPerMinute <= 0 {
+		return c
+	}
+
+	gs.rateLimitersMu.Lock()
+	defer gs.rateLimitersMu.Unlock()
+	if gs.rateLimiters == nil {
+		gs.rateLimiters = map[int64]*rateLimiter{}
+	}
+	limiter, ok := gs.rateLimiters[config.ID]
+	if !ok || limiter.requestsPerMinute != config.RequestsPerMinute {
+		limiter = newRateLimiter(config.RequestsPerMinute)
+		gs.rateLimiters[config.ID] = limiter
+	}
+	return &rateLimitedClient{client: c, limiter: limiter}
+}
@@ -0,0 +1,80 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("calls are throttled to the configured rate", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		fake := &fakeClient{}
+		// 600 requests per minute is one every 100ms, with a burst of 10
+		client := gsd.withRateLimit(fake, &models.DatasourceSettings{ID: 1, RequestsPerMinute: 600})
+
+		start := time.Now()
+		for i := 0; i < 13; i++ {
+			_, err := client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+			require.NoError(t, err)
+		}
+		elapsed := time.Since(start)
+
+		assert.Equal(t, 13, fake.getSpreadsheetCalls)
+		assert.GreaterOrEqual(t, int64(elapsed), int64(250*time.Millisecond))
+		assert.Less(t, int64(elapsed), int64(time.Second))
+	})
+
+	t.Run("waiting respects context cancellation", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		fake := &fakeClient{}
+		client := gsd.withRateLimit(fake, &models.DatasourceSettings{ID: 1, RequestsPerMinute: 1})
+
+		_, err := client.GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = client.GetSpreadsheet(ctx, "someid", "A1:B", true, "")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, fake.getSpreadsheetCalls)
+	})
+
+	t.Run("every client method waits for the limiter", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		fake := &fakeClient{}
+		client := gsd.withRateLimit(fake, &models.DatasourceSettings{ID: 3, RequestsPerMinute: 1})
+
+		_, err := client.GetRowCount(context.Background(), "someid", "Sheet1")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = client.BatchUpdate(ctx, "someid", &sheets.BatchUpdateSpreadsheetRequest{})
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = client.GetSpreadsheetFiles(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = client.GetLatestRevisionID(ctx, "someid")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, fake.batchUpdateRequests)
+	})
+
+	t.Run("limiters are shared by the queries of a datasource", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		config := &models.DatasourceSettings{ID: 1, RequestsPerMinute: 60}
+		first := gsd.withRateLimit(&fakeClient{}, config).(*rateLimitedClient)
+		second := gsd.withRateLimit(&fakeClient{}, config).(*rateLimitedClient)
+		assert.Same(t, first.limiter, second.limiter)
+
+		other := gsd.withRateLimit(&fakeClient{}, &models.DatasourceSettings{ID: 2, RequestsPerMinute: 60}).(*rateLimitedClient)
+		assert.NotSame(t, first.limiter, other.limiter)
+
+		fake := &fakeClient{}
+		assert.Same(t, fake, gsd.withRateLimit(fake, &models.DatasourceSettings{ID: 1}))
+	})
+}
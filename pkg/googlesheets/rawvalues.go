@@ -0,0 +1,36 @@
+package googlesheets
+
+import "google.golang.org/api/sheets/v4"
+
+// defaultRawValuesLimit is the number of raw values included when the query sets no limit.
+const defaultRawValuesLimit = 1000
+
+// getRawValues returns the formatted values of the grid as read from the sheet, header rows included, row by row.
+// At most limit values are returned, the second return value tells whether any were left out.
+func getRawValues(grid *sheets.GridData, limit int) ([][]string, bool) {
+	if limit <= 0 {
+		limit = defaultRawValuesLimit
+	}
+
+	values := [][]string{}
+	count := 0
+	for _, row := range grid.RowData {
+		if count >= limit {
+			return values, true
+		}
+		rowValues := []string{}
+		for _, cell := range row.Values {
+			if count >= limit {
+				return append(values, rowValues), true
+			}
+			value := ""
+			if cell != nil {
+				value = cell.FormattedValue
+			}
+			rowValues = append(rowValues, value)
+			count++
+		}
+		values = append(values, rowValues)
+	}
+	return values, false
+}
@@ -0,0 +1,51 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncludeRawValues(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/packed-values.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("raw values are included as read from the sheet", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", IncludeRawValues: true, SplitColumns: map[string]string{"Tags": ","}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, [][]string{
+			{"Host", "Tags"},
+			{"web-1", "eu,prod,frontend"},
+			{"web-2", "us,staging"},
+			{"db-1", ""},
+		}, meta["rawValues"])
+		assert.NotContains(t, meta, "rawValuesTruncated")
+	})
+
+	t.Run("raw values are capped", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", IncludeRawValues: true, RawValuesLimit: 3}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, [][]string{{"Host", "Tags"}, {"web-1"}}, meta["rawValues"])
+		assert.Equal(t, true, meta["rawValuesTruncated"])
+	})
+
+	t.Run("raw values are left out by default", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.NotContains(t, meta, "rawValues")
+	})
+}
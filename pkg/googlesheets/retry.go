@@ -0,0 +1,153 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxRetriesPerRequest is how often a single request is retried before giving up.
+const maxRetriesPerRequest = 3
+
+// retryDelay is the delay before the first retry, doubled for every following one.
+var retryDelay = 250 * time.Millisecond
+
+// retryBudget is a pool of retries shared by all queries of a datasource and refilled every minute,
+// so a dashboard refresh during an outage can not multiply into hundreds of retries.
+type retryBudget struct {
+	retriesPerMinute int
+
+	mu          sync.Mutex
+	used        int
+	windowStart time.Time
+}
+
+func newRetryBudget(retriesPerMinute int) *retryBudget {
+	return &retryBudget{
+		retriesPerMinute: retriesPerMinute,
+		windowStart:      time.Now(),
+	}
+}
+
+// take uses up a retry, returning false when the budget of the current minute is exhausted.
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now := time.Now(); now.Sub(b.windowStart) >= time.Minute {
+		b.used = 0
+		b.windowStart = now
+	}
+	if b.used >= b.retriesPerMinute {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// retryingClient retries requests that fail with a transient error while the budget lasts. Batch updates are
+// not retried, since they may have been applied even though the request failed.
+type retryingClient struct {
+	client
+	budget *retryBudget
+}
+
+func (c *retryingClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
+	var result *sheets.Spreadsheet
+	err := c.retry(ctx, func() (err error) {
+		result, err = c.client.GetSpreadsheet(ctx, spreadSheetID, sheetRange, includeGridData, fields)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryingClient) GetSpreadsheetRanges(ctx context.Context, spreadSheetID string, sheetRanges []string, fields string) (*sheets.Spreadsheet, error) {
+	var result *sheets.Spreadsheet
+	err := c.retry(ctx, func() (err error) {
+		result, err = c.client.GetSpreadsheetRanges(ctx, spreadSheetID, sheetRanges, fields)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryingClient) GetRowCount(ctx context.Context, spreadSheetID string, sheetRange string) (int, error) {
+	var count int
+	err := c.retry(ctx, func() (err error) {
+		count, err = c.client.GetRowCount(ctx, spreadSheetID, sheetRange)
+		return err
+	})
+	return count, err
+}
+
+func (c *retryingClient) GetSpreadsheetFiles(ctx context.Context) ([]*drive.File, error) {
+	var files []*drive.File
+	err := c.retry(ctx, func() (err error) {
+		files, err = c.client.GetSpreadsheetFiles(ctx)
+		return err
+	})
+	return files, err
+}
+
+func (c *retryingClient) GetLatestRevisionID(ctx context.Context, spreadSheetID string) (string, error) {
+	var revisionID string
+	err := c.retry(ctx, func() (err error) {
+		revisionID, err = c.client.GetLatestRevisionID(ctx, spreadSheetID)
+		return err
+	})
+	return revisionID, err
+}
+
+func (c *retryingClient) GetVersion(ctx context.Context, spreadSheetID string) (int64, error) {
+	var version int64
+	err := c.retry(ctx, func() (err error) {
+		version, err = c.client.GetVersion(ctx, spreadSheetID)
+		return err
+	})
+	return version, err
+}
+
+// retry calls call until it succeeds, fails with an error that is not transient or runs out of retries.
+func (c *retryingClient) retry(ctx context.Context, call func() error) error {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || !isTransientError(err) || attempt == maxRetriesPerRequest {
+			return err
+		}
+		if !c.budget.take() {
+			// Still a transient error, so it is cached like one and the next refresh fails fast as well
+			return fmt.Errorf("retry budget of the datasource is exhausted, consider a longer cache duration: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// withRetryBudget wraps the client with the retry budget of its datasource when RetriesPerMinute is set.
+// Like rate limiters, budgets are shared by all queries of a datasource and replaced when the setting changes.
+func (gs *GoogleSheets) withRetryBudget(c client, config *models.DatasourceSettings) client {
+	if config.RetriesPerMinute <= 0 {
+		return c
+	}
+
+	gs.retryBudgetsMu.Lock()
+	defer gs.retryBudgetsMu.Unlock()
+	if gs.retryBudgets == nil {
+		gs.retryBudgets = map[int64]*retryBudget{}
+	}
+	budget, ok := gs.retryBudgets[config.ID]
+	if !ok || budget.retriesPerMinute != config.RetriesPerMinute {
+		budget = newRetryBudget(config.RetriesPerMinute)
+		gs.retryBudgets[config.ID] = budget
+	}
+	return &retryingClient{client: c, budget: budget}
+}
@@ -0,0 +1,70 @@
+package googlesheets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestRetryBudget(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	t.Run("transient errors are retried until the budget is exhausted", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		config := &models.DatasourceSettings{ID: 1, RetriesPerMinute: 5}
+		fake := &fakeClient{getSpreadsheetErr: &googleapi.Error{Code: http.StatusServiceUnavailable}}
+
+		// Every panel of the refresh shares the budget
+		_, err := gsd.withRetryBudget(fake, config).GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "budget")
+		assert.Equal(t, 1+maxRetriesPerRequest, fake.getSpreadsheetCalls)
+
+		_, err = gsd.withRetryBudget(fake, config).GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "retry budget of the datasource is exhausted")
+		assert.True(t, isTransientError(err), "the error is still transient")
+		assert.Equal(t, 1+maxRetriesPerRequest+1+2, fake.getSpreadsheetCalls)
+
+		_, err = gsd.withRetryBudget(fake, config).GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.Error(t, err)
+		assert.Equal(t, 1+maxRetriesPerRequest+1+2+1, fake.getSpreadsheetCalls, "exhausted budgets fail fast")
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		fake := &fakeClient{getSpreadsheetErr: &googleapi.Error{Code: http.StatusNotFound}}
+		_, err := gsd.withRetryBudget(fake, &models.DatasourceSettings{ID: 1, RetriesPerMinute: 5}).GetSpreadsheet(context.Background(), "someid", "A1:B", true, "")
+		require.Error(t, err)
+		assert.Equal(t, 1, fake.getSpreadsheetCalls)
+	})
+
+	t.Run("other reads are retried, batch updates are not", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		config := &models.DatasourceSettings{ID: 2, RetriesPerMinute: 10}
+		unavailable := &googleapi.Error{Code: http.StatusServiceUnavailable}
+		fake := &fakeClient{filesErr: unavailable, batchUpdateErr: unavailable}
+
+		_, err := gsd.withRetryBudget(fake, config).GetSpreadsheetFiles(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, maxRetriesPerRequest, gsd.retryBudgets[2].used)
+
+		_, err = gsd.withRetryBudget(fake, config).BatchUpdate(context.Background(), "someid", &sheets.BatchUpdateSpreadsheetRequest{})
+		require.Error(t, err)
+		assert.Len(t, fake.batchUpdateRequests, 1)
+	})
+
+	t.Run("retries are disabled by default", func(t *testing.T) {
+		gsd := &GoogleSheets{}
+		fake := &fakeClient{}
+		assert.Same(t, fake, gsd.withRetryBudget(fake, &models.DatasourceSettings{ID: 1}))
+	})
+}
@@ -0,0 +1,21 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+)
+
+// checkRevision makes sure the pinned revision is the latest one. The Sheets API can only read the current
+// state of a spreadsheet, so reading any other revision would silently return different data.
+func checkRevision(ctx context.Context, client client, qm *models.QueryModel) error {
+	latest, err := client.GetLatestRevisionID(ctx, qm.Spreadsheet)
+	if err != nil {
+		return fmt.Errorf("unable to check revision %q: %w", qm.RevisionID, err)
+	}
+	if latest != qm.RevisionID {
+		return fmt.Errorf("reading revision %q is not supported: the Sheets API only returns the latest revision %q", qm.RevisionID, latest)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionID(t *testing.T) {
+	t.Run("the latest revision is read and cached separately", func(t *testing.T) {
+		client := &fakeClient{latestRevisionID: "42"}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O", CacheDurationSeconds: 10}
+		_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+		require.NoError(t, err)
+
+		qm.RevisionID = "42"
+		_, meta, err := gsd.getSheetData(context.Background(), client, &qm)
+		require.NoError(t, err)
+		assert.False(t, meta["hit"].(bool))
+		assert.Equal(t, 2, client.getSpreadsheetCalls)
+
+		t.Run("cached revision data is returned after the spreadsheet changed", func(t *testing.T) {
+			client.latestRevisionID = "43"
+			_, meta, err := gsd.getSheetData(context.Background(), client, &qm)
+			require.NoError(t, err)
+			assert.True(t, meta["hit"].(bool))
+		})
+	})
+
+	t.Run("older revisions are unsupported", func(t *testing.T) {
+		client := &fakeClient{latestRevisionID: "43"}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O", RevisionID: "42"}
+		_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+		assert.EqualError(t, err, `reading revision "42" is not supported: the Sheets API only returns the latest revision "43"`)
+		assert.Equal(t, 0, client.getSpreadsheetCalls)
+	})
+
+	t.Run("a revision created during the fetch is reported", func(t *testing.T) {
+		client := &fakeClient{latestRevisionIDs: []string{"42", "43"}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:O", RevisionID: "42", CacheDurationSeconds: 10}
+		_, _, err := gsd.getSheetData(context.Background(), client, &qm)
+		assert.EqualError(t, err, `reading revision "42" is not supported: the Sheets API only returns the latest revision "43"`)
+		assert.Equal(t, 1, client.getSpreadsheetCalls)
+
+		_, _, err = gsd.getSheetData(context.Background(), client, &qm)
+		assert.Error(t, err, "the data of the later revision is not cached")
+		assert.Equal(t, 1, client.getSpreadsheetCalls)
+	})
+}
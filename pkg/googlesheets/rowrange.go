@@ -0,0 +1,36 @@
+package googlesheets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// rowRangeFieldName is the name of the field with the A1 range of the sheet row of each data row.
+const rowRangeFieldName = "_rowRange"
+
+// getRowRangeField returns the A1 range of the whole sheet row each row was read from, e.g. "'Sheet1'!2:2",
+// so that an update query can target the row.
+func getRowRangeField(rows []*sheets.RowData, rowNumbers map[*sheets.RowData]int, sheetTitle string) *data.Field {
+	ranges := make([]*string, len(rows))
+	for i, row := range rows {
+		rowRange := fmt.Sprintf("%s!%d:%d", quoteSheetName(sheetTitle), rowNumbers[row], rowNumbers[row])
+		ranges[i] = &rowRange
+	}
+	field := data.NewField(rowRangeFieldName, nil, ranges)
+	field.Config = &data.FieldConfig{DisplayName: rowRangeFieldName}
+	return field
+}
+
+// rowRangeNumber returns the sheet row number of a row range made by getRowRangeField.
+func rowRangeNumber(rowRange string) (int, bool) {
+	rows := rowRange[strings.LastIndex(rowRange, "!")+1:]
+	if i := strings.Index(rows, ":"); i >= 0 {
+		rows = rows[:i]
+	}
+	number, err := strconv.Atoi(rows)
+	return number, err == nil
+}
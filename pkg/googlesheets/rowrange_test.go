@@ -0,0 +1,52 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowRange(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/row-ranges.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("row ranges point at the sheet rows after the header and skipped rows", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", IncludeRowRange: true, SkipEmptyRows: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		index := frameFieldIndex(frame, "_rowRange")
+		require.Equal(t, 2, index)
+		field := frame.Fields[index]
+		require.Equal(t, 3, field.Len())
+		assert.Equal(t, "'Order Log'!4:4", *field.At(0).(*string))
+		assert.Equal(t, "'Order Log'!6:6", *field.At(1).(*string))
+		assert.Equal(t, "'Order Log'!7:7", *field.At(2).(*string))
+	})
+
+	t.Run("row ranges follow reversed rows", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", IncludeRowRange: true, ReverseRows: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		field := frame.Fields[frameFieldIndex(frame, "_rowRange")]
+		require.Equal(t, 4, field.Len())
+		assert.Equal(t, "'Order Log'!7:7", *field.At(0).(*string))
+		assert.Equal(t, "'Order Log'!5:5", *field.At(2).(*string))
+		assert.Equal(t, "A-3", *frame.Fields[0].At(0).(*string))
+	})
+
+	t.Run("row ranges are not included by default", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, -1, frameFieldIndex(frame, "_rowRange"))
+		assert.Error(t, validateQuery(&models.QueryModel{IncludeRowRange: true, Transposed: true}))
+	})
+}
@@ -0,0 +1,61 @@
+package googlesheets
+
+import (
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func validateSample(sample models.Sample) error {
+	if sample.EveryNth < 0 || sample.MaxRows < 0 {
+		return fmt.Errorf("sample options must not be negative")
+	}
+	return nil
+}
+
+// sampleRowIndexes picks rows spread evenly across all rows: every Nth row, then at most MaxRows of those.
+// With FromStart the first MaxRows of those are kept instead.
+func sampleRowIndexes(rowCount int, sample models.Sample) []int {
+	indexes := []int{}
+	step := 1
+	if sample.EveryNth > 1 {
+		step = sample.EveryNth
+	}
+	for i := 0; i < rowCount; i += step {
+		indexes = append(indexes, i)
+	}
+
+	if sample.MaxRows > 0 && len(indexes) > sample.MaxRows && sample.FromStart {
+		return indexes[:sample.MaxRows]
+	}
+	if sample.MaxRows > 0 && len(indexes) > sample.MaxRows {
+		picked := make([]int, sample.MaxRows)
+		for i := range picked {
+			picked[i] = indexes[i*len(indexes)/sample.MaxRows]
+		}
+		indexes = picked
+	}
+	return indexes
+}
+
+// sampleFrame returns a frame with only the given rows, keeping the field configs.
+func sampleFrame(frame *data.Frame, indexes []int) *data.Frame {
+	sampled := frame.EmptyCopy()
+	for i, field := range frame.Fields {
+		sampled.Fields[i].Config = field.Config
+	}
+	for _, index := range indexes {
+		sampled.AppendRow(frame.RowCopy(index)...)
+	}
+	return sampled
+}
+
+// reverseFrame returns a frame with the rows in reverse order, keeping the field configs.
+func reverseFrame(frame *data.Frame) *data.Frame {
+	indexes := make([]int, frame.Rows())
+	for i := range indexes {
+		indexes[i] = len(indexes) - 1 - i
+	}
+	return sampleFrame(frame, indexes)
+}
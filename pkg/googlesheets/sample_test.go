@@ -0,0 +1,101 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	t.Run("row indexes are spread evenly", func(t *testing.T) {
+		assert.Equal(t, []int{0, 3, 6, 9}, sampleRowIndexes(10, models.Sample{EveryNth: 3}))
+		assert.Equal(t, []int{0, 2, 5, 7}, sampleRowIndexes(10, models.Sample{MaxRows: 4}))
+		assert.Equal(t, []int{0, 2, 6}, sampleRowIndexes(10, models.Sample{EveryNth: 2, MaxRows: 3}))
+		assert.Equal(t, []int{0, 1, 2}, sampleRowIndexes(3, models.Sample{MaxRows: 5}))
+	})
+
+	t.Run("row indexes are taken from the start", func(t *testing.T) {
+		assert.Equal(t, []int{0, 1, 2, 3}, sampleRowIndexes(10, models.Sample{MaxRows: 4, FromStart: true}))
+		assert.Equal(t, []int{0, 2, 4}, sampleRowIndexes(10, models.Sample{EveryNth: 2, MaxRows: 3, FromStart: true}))
+		assert.Equal(t, []int{0, 1, 2}, sampleRowIndexes(3, models.Sample{MaxRows: 5, FromStart: true}))
+	})
+
+	t.Run("frame is sampled across all rows", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/late-outlier.json")
+		require.NoError(t, err)
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", Sample: models.Sample{MaxRows: 3}}
+		meta := make(map[string]interface{})
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "host-0", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "host-7", *frame.Fields[0].At(1).(*string))
+		assert.Equal(t, "host-14", *frame.Fields[0].At(2).(*string))
+		assert.Equal(t, "Latency", frame.Fields[1].Config.DisplayName)
+		assert.Equal(t, true, meta["sampled"])
+	})
+
+	t.Run("negative options are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{Sample: models.Sample{EveryNth: -1}}))
+	})
+}
+
+func TestReverseRows(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/late-outlier.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("rows are reversed", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ReverseRows: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		forward, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		require.Equal(t, forward.Rows(), frame.Rows())
+		last := forward.Rows() - 1
+		for i := 0; i < frame.Rows(); i++ {
+			assert.Equal(t, forward.Fields[0].At(last-i), frame.Fields[0].At(i))
+			assert.Equal(t, forward.Fields[1].At(last-i), frame.Fields[1].At(i))
+		}
+		assert.Equal(t, "Latency", frame.Fields[1].Config.DisplayName)
+	})
+
+	t.Run("max rows from the start keeps the newest rows", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ReverseRows: true, Sample: models.Sample{MaxRows: 3, FromStart: true}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		forward, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+		last := forward.Rows() - 1
+		for i := 0; i < frame.Rows(); i++ {
+			assert.Equal(t, forward.Fields[0].At(last-i), frame.Fields[0].At(i))
+		}
+	})
+
+	t.Run("max rows without from start is spread over the reversed rows", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", ReverseRows: true, Sample: models.Sample{MaxRows: 3}}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		forward, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+		last := forward.Rows() - 1
+		assert.Equal(t, forward.Fields[0].At(last), frame.Fields[0].At(0))
+		assert.Equal(t, forward.Fields[0].At(last-2*forward.Rows()/3), frame.Fields[0].At(2))
+	})
+}
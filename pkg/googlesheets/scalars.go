@@ -0,0 +1,20 @@
+package googlesheets
+
+import "github.com/grafana/grafana-plugin-sdk-go/data"
+
+// scalarFrames splits a frame with exactly one row into a frame per field, named after the field, for stat panels
+// to show each column as its own single value. Other frames are returned as they are.
+func scalarFrames(frame *data.Frame) []*data.Frame {
+	if frame.Rows() != 1 {
+		return []*data.Frame{frame}
+	}
+
+	frames := make([]*data.Frame, len(frame.Fields))
+	for i, field := range frame.Fields {
+		scalar := data.NewFrame(field.Name, field)
+		scalar.RefID = frame.RefID
+		scalar.Meta = frame.Meta
+		frames[i] = scalar
+	}
+	return frames
+}
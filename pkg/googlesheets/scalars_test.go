@@ -0,0 +1,54 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleRowAsScalars(t *testing.T) {
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("a single row becomes a frame per column", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/single-row.json")
+		require.NoError(t, err)
+		qm := models.QueryModel{Spreadsheet: "someid", SingleRowAsScalars: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		frames, err := finishFrames([]*data.Frame{frame}, &qm, backend.TimeRange{})
+		require.NoError(t, err)
+		require.Equal(t, 3, len(frames))
+		for _, scalar := range frames {
+			assert.Equal(t, "ref1", scalar.RefID)
+			require.Equal(t, 1, len(scalar.Fields))
+			assert.Equal(t, 1, scalar.Rows())
+		}
+		assert.Equal(t, "Revenue", frames[0].Name)
+		assert.Equal(t, 1250.5, *frames[0].Fields[0].At(0).(*float64))
+		assert.Equal(t, "Orders", frames[1].Name)
+		assert.Equal(t, 42.0, *frames[1].Fields[0].At(0).(*float64))
+		assert.Equal(t, "EMEA", *frames[2].Fields[0].At(0).(*string))
+	})
+
+	t.Run("frames with more rows are kept as tables", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/packed-values.json")
+		require.NoError(t, err)
+		qm := models.QueryModel{Spreadsheet: "someid", SingleRowAsScalars: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		frames, err := finishFrames([]*data.Frame{frame}, &qm, backend.TimeRange{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(frames))
+		assert.Same(t, frame, frames[0])
+	})
+}
@@ -0,0 +1,112 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"google.golang.org/api/sheets/v4"
+)
+
+// parseColumnType maps a type name such as "number" to its column type.
+func parseColumnType(name string) (ColumnType, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case string(ColumTypeTime):
+		return ColumTypeTime, nil
+	case ColumTypeNumber:
+		return ColumTypeNumber, nil
+	case ColumTypeString:
+		return ColumTypeString, nil
+	}
+	return "", fmt.Errorf("unknown column type %q", name)
+}
+
+func validateColumnTypes(columnTypes map[string]string) error {
+	for column, name := range columnTypes {
+		if _, err := parseColumnType(name); err != nil {
+			return fmt.Errorf("invalid type for column %q: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// applyColumnTypes forces the configured types, overriding the detected ones.
+// Columns that are not part of the data are ignored.
+func applyColumnTypes(columns []*ColumnDefinition, columnTypes map[string]string) error {
+	for _, column := range columns {
+		name, ok := columnTypes[column.Header]
+		if !ok {
+			continue
+		}
+		columnType, err := parseColumnType(name)
+		if err != nil {
+			return fmt.Errorf("invalid type for column %q: %w", column.Header, err)
+		}
+		column.ForceType(columnType)
+	}
+	return nil
+}
+
+// applyTimeColumn forces the named column to the time type.
+func applyTimeColumn(columns []*ColumnDefinition, timeColumn string) error {
+	column := findColumn(columns, timeColumn)
+	if column == nil {
+		return fmt.Errorf("time column %q not found", timeColumn)
+	}
+	column.ForceType(ColumTypeTime)
+	return nil
+}
+
+// parseSchema reads column types from a two-column (name, type) region. A leading header row is skipped.
+func parseSchema(sheet *sheets.Sheet) (map[string]string, error) {
+	if len(sheet.Data) == 0 {
+		return nil, fmt.Errorf("no grid data found in schema range")
+	}
+
+	columnTypes := map[string]string{}
+	for i, row := range sheet.Data[0].RowData {
+		if len(row.Values) < 2 || row.Values[0] == nil || row.Values[1] == nil {
+			continue
+		}
+		name := strings.TrimSpace(row.Values[0].FormattedValue)
+		typeName := strings.TrimSpace(row.Values[1].FormattedValue)
+		if name == "" || typeName == "" || (i == 0 && strings.EqualFold(typeName, "type")) {
+			continue
+		}
+		if _, err := parseColumnType(typeName); err != nil {
+			return nil, fmt.Errorf("invalid type for column %q in schema range: %w", name, err)
+		}
+		columnTypes[name] = typeName
+	}
+	return columnTypes, nil
+}
+
+// withSchema returns a copy of the query with the column types read from its schema range.
+// Types set on the query itself take precedence over the schema.
+func (gs *GoogleSheets) withSchema(ctx context.Context, client client, qm *models.QueryModel) (*models.QueryModel, error) {
+	schemaQuery := models.QueryModel{
+		Spreadsheet:          qm.Spreadsheet,
+		Range:                qm.SchemaRange,
+		CacheDurationSeconds: qm.CacheDurationSeconds,
+		ErrorCacheDuration:   qm.ErrorCacheDuration,
+		CacheJitter:          qm.CacheJitter,
+		CacheSizeTiers:       qm.CacheSizeTiers,
+	}
+	spreadsheet, _, err := gs.getSheetData(ctx, client, &schemaQuery)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema range %q: %w", qm.SchemaRange, err)
+	}
+
+	columnTypes, err := parseSchema(spreadsheet.Sheets[0])
+	if err != nil {
+		return nil, err
+	}
+	for column, name := range qm.ColumnTypes {
+		columnTypes[column] = name
+	}
+
+	withSchema := *qm
+	withSchema.ColumnTypes = columnTypes
+	return &withSchema, nil
+}
@@ -0,0 +1,60 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestSchemaRange(t *testing.T) {
+	schema, err := loadTestSheet("./testdata/schema.json")
+	require.NoError(t, err)
+	dataSheet, err := loadTestSheet("./testdata/schema-data.json")
+	require.NoError(t, err)
+
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"":           dataSheet,
+		"Schema!A:B": schema,
+		"Data":       dataSheet,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("types from the schema tab override detection", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data", SchemaRange: "Schema!A:B"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		zip := fieldByName(t, frame, "Zip")
+		require.Equal(t, data.FieldTypeNullableString, zip.Type())
+		assert.Equal(t, "10115", *zip.At(0).(*string))
+
+		sales := fieldByName(t, frame, "Sales")
+		require.Equal(t, data.FieldTypeNullableFloat64, sales.Type())
+		assert.Nil(t, sales.At(0))
+		assert.Equal(t, 120.0, *sales.At(1).(*float64))
+
+		assert.Equal(t, data.FieldTypeNullableString, fieldByName(t, frame, "City").Type())
+		// The metadata is read to check if "Data" is a named range
+		assert.Equal(t, []string{"Schema!A:B", "", "Data"}, client.requestedRanges)
+	})
+
+	t.Run("types on the query take precedence over the schema", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Data", SchemaRange: "Schema!A:B", ColumnTypes: map[string]string{"Zip": "number"}}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableFloat64, fieldByName(t, frame, "Zip").Type())
+	})
+
+	t.Run("unknown types are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{ColumnTypes: map[string]string{"Zip": "integer"}}))
+	})
+}
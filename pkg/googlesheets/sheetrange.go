@@ -0,0 +1,320 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"google.golang.org/api/sheets/v4"
+)
+
+const (
+	a1Cell   = `\$?[A-Z]{1,3}\$?\d+`
+	a1Column = `\$?[A-Z]{1,3}`
+	a1Row    = `\$?\d+`
+	a1Part   = `(` + a1Cell + `|` + a1Column + `|` + a1Row + `)`
+)
+
+// sheetlessRangeRegex matches A1 ranges without a sheet name, e.g. "A1:D" or "B2".
+var sheetlessRangeRegex = regexp.MustCompile(`(?i)^(` + a1Part + `:` + a1Part + `|` + a1Cell + `)$`)
+
+// boundedRangeRegex matches ranges that end at a row, e.g. "Sheet1!A1:D100", capturing the sheet prefix,
+// the start cell, the start row, the end column, the anchor of the end row and the end row.
+var boundedRangeRegex = regexp.MustCompile(`(?i)^(.*!)?(\$?[A-Z]{1,3}\$?(\d+)):(\$?[A-Z]{1,3})(\$?)(\d+)$`)
+
+// hasSheetName checks if a range names the sheet it refers to. A bare word like "Data" is a sheet name.
+func hasSheetName(sheetRange string) bool {
+	return strings.Contains(sheetRange, "!") || !sheetlessRangeRegex.MatchString(sheetRange)
+}
+
+// quoteSheetName quotes a sheet name for use in an A1 range.
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// sheetNameOf returns the unquoted sheet name of a range, e.g. "Sales" for "'Sales'!A1:B".
+func sheetNameOf(sheetRange string) string {
+	name := sheetRange
+	if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		name = sheetRange[:i]
+	}
+	if len(name) >= 2 && strings.HasPrefix(name, "'") && strings.HasSuffix(name, "'") {
+		name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+	}
+	return name
+}
+
+// resolveFirstVisibleSheet gets the title of the first sheet that is not hidden.
+func (gs *GoogleSheets) resolveFirstVisibleSheet(ctx context.Context, client client, qm *models.QueryModel) (string, error) {
+	metadataQuery := *qm
+	metadataQuery.Range = ""
+	spreadsheet, _, err := gs.getSpreadsheetMetadata(ctx, client, &metadataQuery)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && !sheet.Properties.Hidden {
+			return sheet.Properties.Title, nil
+		}
+	}
+	return "", fmt.Errorf("range %q has no sheet name and every sheet is hidden", qm.Range)
+}
+
+// resolveNamedRange maps a range that is a bare name, e.g. "Totals", to the A1 range of the named range
+// with that name on its owning sheet. Names are matched ignoring case, like Sheets does. found is false
+// if there is no such named range.
+func (gs *GoogleSheets) resolveNamedRange(ctx context.Context, client client, qm *models.QueryModel) (resolved string, found bool, err error) {
+	spreadsheet, err := gs.getNamedRangeSheets(ctx, client, qm.Spreadsheet)
+	if err != nil {
+		return "", false, err
+	}
+
+	titles := map[int64]string{}
+	candidates := []string{}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties == nil {
+			continue
+		}
+		titles[sheet.Properties.SheetId] = sheet.Properties.Title
+		if strings.EqualFold(sheet.Properties.Title, qm.Range) {
+			candidates = append(candidates, fmt.Sprintf("sheet %q", sheet.Properties.Title))
+		}
+	}
+
+	for _, namedRange := range spreadsheet.NamedRanges {
+		if namedRange.Range == nil || !strings.EqualFold(namedRange.Name, qm.Range) {
+			continue
+		}
+		title, ok := titles[namedRange.Range.SheetId]
+		if !ok {
+			return "", false, fmt.Errorf("named range %q refers to a sheet that does not exist", namedRange.Name)
+		}
+		resolved = gridRangeToA1(title, namedRange.Range)
+		found = true
+		candidates = append(candidates, fmt.Sprintf("named range %q (%s)", namedRange.Name, resolved))
+	}
+
+	if found && len(candidates) > 1 {
+		return "", false, fmt.Errorf("range %q is ambiguous, it matches %s", qm.Range, strings.Join(candidates, ", "))
+	}
+	return resolved, found, nil
+}
+
+// namedRangesFields limits the spreadsheet to what named ranges are resolved with.
+const namedRangesFields = "namedRanges,sheets.properties"
+
+// getNamedRangeSheets gets the named ranges and sheet properties of a spreadsheet. They are cached with the default
+// expiration regardless of the cache duration of the query, since every query with a bare name needs them.
+func (gs *GoogleSheets) getNamedRangeSheets(ctx context.Context, client client, spreadsheetID string) (*sheets.Spreadsheet, error) {
+	cacheKey := "namedRanges:" + spreadsheetID
+	if item, found := gs.Cache.Get(cacheKey); found {
+		return item.(*sheets.Spreadsheet), nil
+	}
+
+	result, err := client.GetSpreadsheet(ctx, spreadsheetID, "", false, namedRangesFields)
+	if err != nil {
+		return nil, err
+	}
+	gs.Cache.Set(cacheKey, result, cache.DefaultExpiration)
+	return result, nil
+}
+
+// resolveMetadataRange reads the range to query from the value of a developer metadata key of the spreadsheet or
+// one of its sheets, so it can be changed without editing the query.
+func (gs *GoogleSheets) resolveMetadataRange(ctx context.Context, client client, qm *models.QueryModel) (string, error) {
+	metadataQuery := *qm
+	metadataQuery.Range = ""
+	spreadsheet, _, err := gs.getSpreadsheetMetadata(ctx, client, &metadataQuery)
+	if err != nil {
+		return "", err
+	}
+
+	metadata := append([]*sheets.DeveloperMetadata{}, spreadsheet.DeveloperMetadata...)
+	for _, sheet := range spreadsheet.Sheets {
+		metadata = append(metadata, sheet.DeveloperMetadata...)
+	}
+	resolved := ""
+	for _, entry := range metadata {
+		if entry.MetadataKey != qm.RangeFromMetadataKey {
+			continue
+		}
+		if resolved != "" && entry.MetadataValue != resolved {
+			return "", fmt.Errorf("developer metadata key %q is ambiguous, it has the ranges %q and %q", qm.RangeFromMetadataKey, resolved, entry.MetadataValue)
+		}
+		resolved = entry.MetadataValue
+	}
+	if resolved == "" {
+		return "", fmt.Errorf("developer metadata key %q not found or has no range", qm.RangeFromMetadataKey)
+	}
+	return resolved, nil
+}
+
+// expandRange extends the end row of a bounded range to the last row of the sheet, if the sheet goes past it.
+// Trailing empty rows are left out of grid data, so reading to the end of the sheet only adds rows with data.
+func expandRange(ctx context.Context, client client, qm *models.QueryModel) (string, error) {
+	match := boundedRangeRegex.FindStringSubmatch(qm.Range)
+	if match == nil {
+		return qm.Range, nil
+	}
+	endRow, _ := strconv.Atoi(match[6])
+
+	lastRow, err := client.GetRowCount(ctx, qm.Spreadsheet, qm.Range)
+	if err != nil {
+		return "", err
+	}
+	if lastRow <= endRow {
+		return qm.Range, nil
+	}
+	return fmt.Sprintf("%s%s:%s%s%d", match[1], match[2], match[4], match[5], lastRow), nil
+}
+
+var (
+	startCellRegex = regexp.MustCompile(`(?i)^\$?([A-Z]{1,3})\$?(\d+)$`)
+	endCellRegex   = regexp.MustCompile(`(?i)^\$?([A-Z]{1,3})(?:\$?(\d+))?$`)
+)
+
+// composeRange builds the A1 range of a query from its SheetName, StartCell and EndCell. The end cell may be
+// just a column, e.g. "D", to read to the last row with data.
+func composeRange(qm *models.QueryModel) (string, error) {
+	if qm.Range != "" {
+		return "", fmt.Errorf("range %q can not be combined with a sheet name, start cell or end cell", qm.Range)
+	}
+
+	sheetRange := ""
+	if qm.StartCell != "" || qm.EndCell != "" {
+		start := startCellRegex.FindStringSubmatch(qm.StartCell)
+		if start == nil {
+			return "", fmt.Errorf("invalid start cell %q, expected a cell like A1", qm.StartCell)
+		}
+		sheetRange = strings.ToUpper(qm.StartCell)
+
+		if qm.EndCell != "" {
+			end := endCellRegex.FindStringSubmatch(qm.EndCell)
+			if end == nil {
+				return "", fmt.Errorf("invalid end cell %q, expected a cell like D100 or a column like D", qm.EndCell)
+			}
+			if columnNumber(end[1]) < columnNumber(start[1]) {
+				return "", fmt.Errorf("end cell %q is left of start cell %q", qm.EndCell, qm.StartCell)
+			}
+			if end[2] != "" {
+				startRow, _ := strconv.Atoi(start[2])
+				endRow, _ := strconv.Atoi(end[2])
+				if endRow < startRow {
+					return "", fmt.Errorf("end cell %q is above start cell %q", qm.EndCell, qm.StartCell)
+				}
+			}
+			sheetRange += ":" + strings.ToUpper(qm.EndCell)
+		}
+	}
+
+	if qm.SheetName == "" {
+		return sheetRange, nil
+	}
+	if sheetRange == "" {
+		return quoteSheetName(qm.SheetName), nil
+	}
+	return quoteSheetName(qm.SheetName) + "!" + sheetRange, nil
+}
+
+// columnNumber is the inverse of getExcelColumnName, e.g. 28 for "AB".
+func columnNumber(name string) int {
+	number := 0
+	for _, r := range strings.ToUpper(name) {
+		number = number*26 + int(r-'A') + 1
+	}
+	return number
+}
+
+var (
+	a1PartRegex       = regexp.MustCompile(`(?i)^` + a1Part + `$`)
+	a1CellRegex       = regexp.MustCompile(`(?i)^` + a1Cell + `$`)
+	plainSheetRegex   = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	r1c1CellRegex     = regexp.MustCompile(`(?i)^R\d*C\d*$`)
+	a1PartColumnRegex = regexp.MustCompile(`(?i)^\$?([A-Z]*)`)
+)
+
+// normalizeRange quotes the sheet name of an A1 range when it needs quotes and uppercases its cells, e.g.
+// "'My Sheet'!A1:B" for "My Sheet!a1:b". Ranges without cells, like a sheet or named range name, are kept.
+func normalizeRange(sheetRange string) (string, error) {
+	sheet, cells := "", sheetRange
+	hasSheet := false
+	if strings.HasPrefix(sheetRange, "'") {
+		end := closingQuote(sheetRange)
+		if end < 0 {
+			return "", fmt.Errorf("range %q has an unbalanced quote in its sheet name", sheetRange)
+		}
+		sheet, cells = sheetRange[:end+1], sheetRange[end+1:]
+		if cells == "" {
+			return sheetRange, nil
+		}
+		if !strings.HasPrefix(cells, "!") {
+			return "", fmt.Errorf("range %q has text after its quoted sheet name, expected ! and the cells", sheetRange)
+		}
+		if sheet == "''" {
+			return "", fmt.Errorf("range %q has an empty sheet name", sheetRange)
+		}
+		cells, hasSheet = cells[1:], true
+	} else if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		sheet, cells, hasSheet = sheetRange[:i], sheetRange[i+1:], true
+		if strings.TrimSpace(sheet) == "" {
+			return "", fmt.Errorf("range %q has an empty sheet name", sheetRange)
+		}
+		// Names like A1 or R1C1 would be read as cells
+		if !plainSheetRegex.MatchString(sheet) || a1CellRegex.MatchString(sheet) || r1c1CellRegex.MatchString(sheet) {
+			sheet = quoteSheetName(sheet)
+		}
+	} else if !sheetlessRangeRegex.MatchString(sheetRange) {
+		// A sheet or named range name
+		return sheetRange, nil
+	}
+
+	if cells == "" {
+		return "", fmt.Errorf("range %q has no cells after its sheet name", sheetRange)
+	}
+	parts := strings.Split(cells, ":")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("range %q has more than two cells, expected cells like A1:D100", sheetRange)
+	}
+	for i, part := range parts {
+		if !a1PartRegex.MatchString(part) {
+			return "", fmt.Errorf("invalid cell %q in range %q, expected a cell like A1, a column like A or a row like 1", part, sheetRange)
+		}
+		parts[i] = strings.ToUpper(part)
+	}
+	if len(parts) == 1 && !a1CellRegex.MatchString(parts[0]) {
+		return "", fmt.Errorf("invalid cell %q in range %q, a single cell needs a column and a row like A1", parts[0], sheetRange)
+	}
+	if len(parts) == 2 {
+		startColumn := a1PartColumnRegex.FindStringSubmatch(parts[0])[1]
+		endColumn := a1PartColumnRegex.FindStringSubmatch(parts[1])[1]
+		if startColumn != "" && endColumn != "" && columnNumber(endColumn) < columnNumber(startColumn) {
+			return "", fmt.Errorf("range %q ends left of where it starts", sheetRange)
+		}
+	}
+
+	normalized := strings.Join(parts, ":")
+	if hasSheet {
+		normalized = sheet + "!" + normalized
+	}
+	return normalized, nil
+}
+
+// closingQuote returns the index of the quote ending a quoted sheet name, skipping escaped quotes, or -1.
+func closingQuote(sheetRange string) int {
+	for i := 1; i < len(sheetRange); i++ {
+		if sheetRange[i] != '\'' {
+			continue
+		}
+		if i+1 < len(sheetRange) && sheetRange[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
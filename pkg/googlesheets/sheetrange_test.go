@@ -0,0 +1,343 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestHasSheetName(t *testing.T) {
+	for sheetRange, expected := range map[string]bool{
+		"A1:D":       false,
+		"B2":         false,
+		"A:C":        false,
+		"2:10":       false,
+		"$A$1:$B$5":  false,
+		"Data":       true,
+		"Sheet1!A:D": true,
+		"'My tab'":   true,
+	} {
+		assert.Equal(t, expected, hasSheetName(sheetRange), sheetRange)
+	}
+}
+
+func TestSheetNameOf(t *testing.T) {
+	for sheetRange, expected := range map[string]string{
+		"Sheet1!A:D":       "Sheet1",
+		"'My tab'!A1":      "My tab",
+		"'Bob''s tab'!A:B": "Bob's tab",
+		"Data":             "Data",
+	} {
+		assert.Equal(t, expected, sheetNameOf(sheetRange), sheetRange)
+	}
+}
+
+func TestFirstVisibleSheet(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/hidden-first-sheet.json")
+	require.NoError(t, err)
+
+	report := *spreadsheet
+	report.Sheets = spreadsheet.Sheets[1:]
+
+	t.Run("range without a sheet name resolves to the first visible sheet", func(t *testing.T) {
+		client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+			"":              spreadsheet,
+			"'Report'!A1:D": &report,
+		}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:D"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		meta := frame.Meta.Custom.(map[string]interface{})
+		assert.Equal(t, "Report", meta["resolvedSheet"])
+		assert.Equal(t, "Region", frame.Fields[0].Name)
+		assert.Equal(t, "A1:D", qm.Range)
+	})
+
+	t.Run("every sheet being hidden is an error", func(t *testing.T) {
+		allHidden := *spreadsheet
+		allHidden.Sheets = spreadsheet.Sheets[:1]
+		client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{"": &allHidden}}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "A1:D"}
+		_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		assert.Error(t, err)
+	})
+}
+
+func TestNamedRange(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/named-ranges.json")
+	require.NoError(t, err)
+
+	summary := *spreadsheet
+	summary.Sheets = spreadsheet.Sheets[1:]
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"":                spreadsheet,
+		"'Summary'!A1:B3": &summary,
+		"Sheet1":          spreadsheet,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("a global named range resolves to its owning sheet", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "totals"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		meta := frame.Meta.Custom.(map[string]interface{})
+		assert.Equal(t, "'Summary'!A1:B3", meta["resolvedNamedRange"])
+		assert.Equal(t, "'Summary'!A1:B3", client.requestedRanges[len(client.requestedRanges)-1])
+		assert.Equal(t, "Metric", frame.Fields[0].Name)
+	})
+
+	t.Run("sheet names are read as sheets", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sheet1"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.NotContains(t, frame.Meta.Custom.(map[string]interface{}), "resolvedNamedRange")
+		assert.Equal(t, "Sheet1", client.requestedRanges[len(client.requestedRanges)-1])
+	})
+
+	t.Run("names matching a sheet and a named range are ambiguous", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Summary"}
+		_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		assert.EqualError(t, err, `range "Summary" is ambiguous, it matches sheet "Summary", named range "summary" ('Sheet1'!B:B)`)
+	})
+
+	t.Run("named ranges are read once with a field mask", func(t *testing.T) {
+		client := &fakeClient{sheetsByRange: client.sheetsByRange}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		for i := 0; i < 2; i++ {
+			qm := models.QueryModel{Spreadsheet: "someid", Range: "totals"}
+			_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, []string{"", "'Summary'!A1:B3", "'Summary'!A1:B3"}, client.requestedRanges)
+		assert.Equal(t, namedRangesFields, client.requestedFields[0])
+	})
+}
+
+func TestRangeFromMetadataKey(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/developer-metadata.json")
+	require.NoError(t, err)
+
+	published := *spreadsheet
+	published.Sheets = spreadsheet.Sheets[1:]
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"":                  spreadsheet,
+		"'Published'!A1:B3": &published,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("the range is read from the metadata value", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RangeFromMetadataKey: "dashboard_range"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "'Published'!A1:B3", client.requestedRanges[len(client.requestedRanges)-1])
+		assert.Equal(t, "'Published'!A1:B3", frame.Meta.Custom.(map[string]interface{})["metadataRange"])
+		assert.Equal(t, "Total", frame.Fields[1].Name)
+	})
+
+	t.Run("missing keys are an error", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RangeFromMetadataKey: "missing_range"}
+		_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		assert.EqualError(t, err, `developer metadata key "missing_range" not found or has no range`)
+	})
+
+	t.Run("keys with several ranges are ambiguous", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", RangeFromMetadataKey: "staging_range"}
+		_, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		assert.Error(t, err)
+	})
+
+	t.Run("the key replaces the range", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{RangeFromMetadataKey: "dashboard_range", Range: "A1:B"}))
+	})
+}
+
+func TestAutoExpandRange(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/orders.json")
+	require.NoError(t, err)
+
+	truncated := *spreadsheet
+	sheet := *spreadsheet.Sheets[0]
+	grid := *sheet.Data[0]
+	grid.RowData = grid.RowData[:2]
+	sheet.Data = []*sheets.GridData{&grid}
+	truncated.Sheets = []*sheets.Sheet{&sheet}
+
+	client := &fakeClient{rowCount: 4, sheetsByRange: map[string]*sheets.Spreadsheet{
+		"Sheet1!A1:C2": &truncated,
+		"Sheet1!A1:C4": spreadsheet,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("ranges are expanded when the data overflows them", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sheet1!A1:C2", AutoExpandRange: true}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "Sheet1!A1:C4", client.requestedRanges[len(client.requestedRanges)-1])
+		warnings := frame.Meta.Custom.(map[string]interface{})["warnings"].([]string)
+		assert.Equal(t, `Data extends past range "Sheet1!A1:C2", expanded it to "Sheet1!A1:C4"`, warnings[0])
+	})
+
+	t.Run("explicit ranges are kept by default", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sheet1!A1:C2"}
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, frame.Rows())
+		assert.Equal(t, "Sheet1!A1:C2", client.requestedRanges[len(client.requestedRanges)-1])
+	})
+
+	t.Run("ranges that fit the data are not expanded", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Range: "Sheet1!A1:C10", AutoExpandRange: true}
+		client.sheetsByRange["Sheet1!A1:C10"] = spreadsheet
+		frame, err := gsd.querySheet(context.Background(), client, "A", &qm)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Sheet1!A1:C10", client.requestedRanges[len(client.requestedRanges)-1])
+		assert.Empty(t, frame.Meta.Custom.(map[string]interface{})["warnings"])
+	})
+
+	t.Run("ranges without an end row are left alone", func(t *testing.T) {
+		assert.Equal(t, "Sheet1!A1:C", mustExpandRange(t, client, "Sheet1!A1:C"))
+		assert.Equal(t, "Sheet1", mustExpandRange(t, client, "Sheet1"))
+	})
+
+	t.Run("ranges are expanded to the last row of the sheet", func(t *testing.T) {
+		assert.Equal(t, "'My Sheet'!B2:C4", mustExpandRange(t, client, "'My Sheet'!B2:C3"))
+	})
+
+	t.Run("anchors of absolute ranges are kept", func(t *testing.T) {
+		assert.Equal(t, "Sheet1!$A$1:$C$4", mustExpandRange(t, client, "Sheet1!$A$1:$C$2"))
+		assert.Equal(t, "Sheet1!$A1:C4", mustExpandRange(t, client, "Sheet1!$A1:C2"))
+	})
+}
+
+func mustExpandRange(t *testing.T, client client, sheetRange string) string {
+	t.Helper()
+	expanded, err := expandRange(context.Background(), client, &models.QueryModel{Spreadsheet: "someid", Range: sheetRange})
+	require.NoError(t, err)
+	return expanded
+}
+
+func TestComposeRange(t *testing.T) {
+	tests := []struct {
+		qm       models.QueryModel
+		expected string
+	}{
+		{models.QueryModel{SheetName: "Sales", StartCell: "A1", EndCell: "D100"}, "'Sales'!A1:D100"},
+		{models.QueryModel{SheetName: "Sales", StartCell: "b2", EndCell: "d"}, "'Sales'!B2:D"},
+		{models.QueryModel{SheetName: "Bob's data"}, "'Bob''s data'"},
+		{models.QueryModel{StartCell: "$A$1", EndCell: "C3"}, "$A$1:C3"},
+		{models.QueryModel{SheetName: "Sales", StartCell: "C5"}, "'Sales'!C5"},
+		{models.QueryModel{StartCell: "Z1", EndCell: "AA1"}, "Z1:AA1"},
+	}
+	for _, test := range tests {
+		composed, err := composeRange(&test.qm)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, composed)
+	}
+
+	t.Run("invalid and inverted cells are rejected", func(t *testing.T) {
+		for qm, expected := range map[*models.QueryModel]string{
+			{StartCell: "D1", EndCell: "A10"}:           `end cell "A10" is left of start cell "D1"`,
+			{StartCell: "A10", EndCell: "D1"}:           `end cell "D1" is above start cell "A10"`,
+			{StartCell: "A", EndCell: "D1"}:             `invalid start cell "A", expected a cell like A1`,
+			{StartCell: "A1", EndCell: "1D"}:            `invalid end cell "1D", expected a cell like D100 or a column like D`,
+			{EndCell: "D1"}:                             `invalid start cell "", expected a cell like A1`,
+			{Range: "Sheet1!A1:B", SheetName: "Sheet1"}: `range "Sheet1!A1:B" can not be combined with a sheet name, start cell or end cell`,
+		} {
+			_, err := composeRange(qm)
+			assert.EqualError(t, err, expected)
+		}
+	})
+}
+
+func TestNormalizeRange(t *testing.T) {
+	t.Run("fixable ranges are normalized", func(t *testing.T) {
+		for sheetRange, expected := range map[string]string{
+			"My Sheet!A1:B":       "'My Sheet'!A1:B",
+			"Sheet1!a1:d100":      "Sheet1!A1:D100",
+			"'My Sheet'!b2:c":     "'My Sheet'!B2:C",
+			"Bob's data!$a$1:C3":  "'Bob''s data'!$A$1:C3",
+			"'It''s here'!a:a":    "'It''s here'!A:A",
+			"Q1 2021!2:10":        "'Q1 2021'!2:10",
+			"a1:c":                "A1:C",
+			"My Sheet":            "My Sheet",
+			"'My Sheet'":          "'My Sheet'",
+			"Sheet1!A1:D100":      "Sheet1!A1:D100",
+			"Totals!result!a1:b2": "'Totals!result'!A1:B2",
+			"A1!a1:b2":            "'A1'!A1:B2",
+			"r1c1!a1:b2":          "'r1c1'!A1:B2",
+			"Results2!a1:b2":      "Results2!A1:B2",
+		} {
+			normalized, err := normalizeRange(sheetRange)
+			require.NoError(t, err, sheetRange)
+			assert.Equal(t, expected, normalized, sheetRange)
+		}
+	})
+
+	t.Run("malformed ranges are rejected", func(t *testing.T) {
+		for sheetRange, expected := range map[string]string{
+			"'My Sheet!A1:B":  `range "'My Sheet!A1:B" has an unbalanced quote in its sheet name`,
+			"'My Sheet'A1:B":  `range "'My Sheet'A1:B" has text after its quoted sheet name, expected ! and the cells`,
+			"!A1:B":           `range "!A1:B" has an empty sheet name`,
+			"''!A1:B":         `range "''!A1:B" has an empty sheet name`,
+			"Sheet1!":         `range "Sheet1!" has no cells after its sheet name`,
+			"Sheet1!A1:B2:C3": `range "Sheet1!A1:B2:C3" has more than two cells, expected cells like A1:D100`,
+			"Sheet1!A1:1B":    `invalid cell "1B" in range "Sheet1!A1:1B", expected a cell like A1, a column like A or a row like 1`,
+			"Sheet1!ABCD1":    `invalid cell "ABCD1" in range "Sheet1!ABCD1", expected a cell like A1, a column like A or a row like 1`,
+			"Sheet1!B":        `invalid cell "B" in range "Sheet1!B", a single cell needs a column and a row like A1`,
+			"Sheet1!D1:A10":   `range "Sheet1!D1:A10" ends left of where it starts`,
+		} {
+			_, err := normalizeRange(sheetRange)
+			assert.EqualError(t, err, expected, sheetRange)
+		}
+	})
+
+	t.Run("malformed ranges fail the query before any request", func(t *testing.T) {
+		gsd := &GoogleSheets{Cache: cache.New(300*time.Second, 50*time.Second)}
+		qm := &models.QueryModel{Spreadsheet: "someid", Range: "Sheet1!A1:1B", NormalizeRange: true}
+		dr := gsd.Query(context.Background(), "A", qm, &models.DatasourceSettings{}, backend.TimeRange{})
+		assert.EqualError(t, dr.Error, `invalid cell "1B" in range "Sheet1!A1:1B", expected a cell like A1, a column like A or a row like 1`)
+	})
+
+	t.Run("the ranges of every tab are normalized", func(t *testing.T) {
+		sheetRanges := map[string]string{"Sales": "a1:c", "Costs": ""}
+		qm := &models.QueryModel{Spreadsheet: "someid", SheetRanges: sheetRanges, NormalizeRange: true}
+		require.NoError(t, prepareQuery(qm))
+		assert.Equal(t, map[string]string{"Sales": "A1:C", "Costs": ""}, qm.SheetRanges)
+		assert.Equal(t, "a1:c", sheetRanges["Sales"], "the ranges of the query are not changed in place")
+
+		qm = &models.QueryModel{Spreadsheet: "someid", SheetRanges: map[string]string{"Sales": "A1:1B"}, NormalizeRange: true}
+		assert.EqualError(t, prepareQuery(qm), `invalid cell "1B" in range "'Sales'!A1:1B", expected a cell like A1, a column like A or a row like 1`)
+	})
+}
@@ -0,0 +1,119 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
+)
+
+// snapshotTimeFormat is used for the title of snapshot sheets.
+const snapshotTimeFormat = "2006-01-02 15:04:05"
+
+// validateSnapshot rejects the queries that return more than one frame, since a snapshot is written to one sheet.
+func validateSnapshot(qm *models.QueryModel) error {
+	if qm.FramePerSheet || len(qm.SheetRanges) > 0 || isDateTemplateRange(qm.Range) || qm.SingleRowAsScalars {
+		return fmt.Errorf("a snapshot is written to one sheet and can not be taken of a query returning several frames")
+	}
+	return nil
+}
+
+// snapshot reads the range of the query and writes the resulting frame to a new, timestamped sheet of the
+// same spreadsheet. It returns a frame with the id and title of the new sheet. The sheet is added and filled
+// in one batchUpdate, so a failed write leaves no empty sheet behind.
+func (gs *GoogleSheets) snapshot(ctx context.Context, client client, refID string, qm *models.QueryModel, config *models.DatasourceSettings, timeRange backend.TimeRange) (*data.Frame, error) {
+	if !config.AllowWrites {
+		return nil, fmt.Errorf("snapshots need writes to be enabled for this datasource")
+	}
+
+	frame, err := gs.querySheet(ctx, client, refID, qm)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := finishFrames([]*data.Frame{frame}, qm, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) != 1 {
+		return nil, fmt.Errorf("a snapshot is written to one sheet, but the query returned %d frames", len(frames))
+	}
+	frame = frames[0]
+
+	sheetID, err := newSheetID()
+	if err != nil {
+		return nil, err
+	}
+	// The random sheet id keeps titles unique even for snapshots taken in the same second
+	title := fmt.Sprintf("Snapshot %s #%d", time.Now().Format(snapshotTimeFormat), sheetID)
+	_, err = client.BatchUpdate(ctx, qm.Spreadsheet, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{SheetId: sheetID, Title: title}}},
+			{AppendCells: &sheets.AppendCellsRequest{
+				SheetId: sheetID,
+				Rows:    frameToRows(frame),
+				Fields:  "userEnteredValue",
+			}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write snapshot sheet %q: %w", title, err)
+	}
+
+	result := data.NewFrame(refID,
+		data.NewField("sheetId", nil, []int64{sheetID}),
+		data.NewField("title", nil, []string{title}),
+		data.NewField("rows", nil, []int64{int64(frame.Rows())}),
+	)
+	result.RefID = refID
+	return result, nil
+}
+
+// frameToRows converts a frame to sheet rows, with the field names as the header row.
+func frameToRows(frame *data.Frame) []*sheets.RowData {
+	header := make([]*sheets.CellData, len(frame.Fields))
+	for i, field := range frame.Fields {
+		name := field.Name
+		header[i] = &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &name}}
+	}
+
+	rows := []*sheets.RowData{{Values: header}}
+	for rowIndex := 0; rowIndex < frame.Rows(); rowIndex++ {
+		values := make([]*sheets.CellData, len(frame.Fields))
+		for i, field := range frame.Fields {
+			values[i] = &sheets.CellData{UserEnteredValue: snapshotValue(field, rowIndex)}
+		}
+		rows = append(rows, &sheets.RowData{Values: values})
+	}
+	return rows
+}
+
+// snapshotValue converts a field value to a cell value, nil for null values.
+func snapshotValue(field *data.Field, rowIndex int) *sheets.ExtendedValue {
+	value, ok := field.ConcreteAt(rowIndex)
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return &sheets.ExtendedValue{NumberValue: &v}
+	case int64:
+		number := float64(v)
+		return &sheets.ExtendedValue{NumberValue: &number}
+	case bool:
+		return &sheets.ExtendedValue{BoolValue: &v}
+	case time.Time:
+		// With the offset, so that the time doesn't depend on the zone of the spreadsheet or the server
+		s := v.Format(time.RFC3339)
+		return &sheets.ExtendedValue{StringValue: &s}
+	case string:
+		return &sheets.ExtendedValue{StringValue: &v}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return &sheets.ExtendedValue{StringValue: &s}
+	}
+}
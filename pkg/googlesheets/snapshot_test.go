@@ -0,0 +1,82 @@
+package googlesheets
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestSnapshot(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/orders.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+	qm := models.QueryModel{Spreadsheet: "someid", Range: "Sheet1"}
+	writable := &models.DatasourceSettings{AllowWrites: true}
+
+	t.Run("the sheet is added and filled in one batch update", func(t *testing.T) {
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		result, err := gsd.snapshot(context.Background(), client, "A", &qm, writable, backend.TimeRange{})
+		require.NoError(t, err)
+
+		require.Len(t, client.batchUpdateRequests, 1)
+		requests := client.batchUpdateRequests[0].Requests
+		require.Len(t, requests, 2)
+		addSheet := requests[0].AddSheet
+		require.NotNil(t, addSheet)
+		assert.True(t, strings.HasPrefix(addSheet.Properties.Title, "Snapshot "))
+		assert.NotZero(t, addSheet.Properties.SheetId)
+
+		appendCells := requests[1].AppendCells
+		require.NotNil(t, appendCells)
+		assert.Equal(t, addSheet.Properties.SheetId, appendCells.SheetId)
+		require.Len(t, appendCells.Rows, 4)
+		assert.Equal(t, "Product", *appendCells.Rows[0].Values[0].UserEnteredValue.StringValue)
+		source := spreadsheet.Sheets[0].Data[0].RowData[1].Values
+		assert.Equal(t, source[0].FormattedValue, *appendCells.Rows[1].Values[0].UserEnteredValue.StringValue)
+		assert.Equal(t, *source[1].EffectiveValue.NumberValue, *appendCells.Rows[1].Values[1].UserEnteredValue.NumberValue)
+
+		assert.Equal(t, addSheet.Properties.SheetId, result.Fields[0].At(0))
+		assert.Equal(t, addSheet.Properties.Title, result.Fields[1].At(0))
+		assert.Equal(t, int64(3), result.Fields[2].At(0))
+	})
+
+	t.Run("titles of snapshots taken at once are unique", func(t *testing.T) {
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		first, err := gsd.snapshot(context.Background(), client, "A", &qm, writable, backend.TimeRange{})
+		require.NoError(t, err)
+		second, err := gsd.snapshot(context.Background(), client, "A", &qm, writable, backend.TimeRange{})
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Fields[1].At(0), second.Fields[1].At(0))
+	})
+
+	t.Run("writes must be allowed", func(t *testing.T) {
+		client := &fakeClient{responses: []*sheets.Spreadsheet{spreadsheet}}
+		_, err := gsd.snapshot(context.Background(), client, "A", &qm, &models.DatasourceSettings{}, backend.TimeRange{})
+		assert.EqualError(t, err, "snapshots need writes to be enabled for this datasource")
+		assert.Empty(t, client.batchUpdateRequests)
+	})
+
+	t.Run("queries returning several frames are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{QueryType: models.QueryTypeSnapshot, FramePerSheet: true}))
+		assert.Error(t, validateQuery(&models.QueryModel{QueryType: models.QueryTypeSnapshot, SheetRanges: map[string]string{"Sheet1": ""}}))
+		assert.Error(t, validateQuery(&models.QueryModel{QueryType: models.QueryTypeSnapshot, SingleRowAsScalars: true}))
+		assert.NoError(t, validateQuery(&models.QueryModel{QueryType: models.QueryTypeSnapshot, Range: "Sheet1"}))
+	})
+
+	t.Run("times are written with their offset", func(t *testing.T) {
+		when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600))
+		field := data.NewField("Time", nil, []*time.Time{&when})
+		assert.Equal(t, "2021-03-04T05:06:07+01:00", *snapshotValue(field, 0).StringValue)
+	})
+}
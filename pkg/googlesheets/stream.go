@@ -0,0 +1,207 @@
+package googlesheets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// defaultStreamInterval is how often a streamed range is polled when the query has no interval.
+const defaultStreamInterval = 10 * time.Second
+
+// streamRegistrationTTL is how long a streamed query is kept for a first subscriber.
+const streamRegistrationTTL = 10 * time.Minute
+
+// streamQuery is a query that was run with Stream enabled.
+type streamQuery struct {
+	refID      string
+	qm         *models.QueryModel
+	registered time.Time
+	running    bool
+}
+
+// streamKey identifies the stream of a path, which is only unique within a datasource.
+func streamKey(uid string, path string) string {
+	return uid + "/" + path
+}
+
+// registerStream remembers a streamed query under a path derived from it, so a subscription to the path can
+// run the query again. Streams always read fresh data, since polling a cached range never sees new rows.
+// Streams nobody subscribed to are forgotten after streamRegistrationTTL.
+func (gs *GoogleSheets) registerStream(uid string, refID string, qm *models.QueryModel) (string, error) {
+	streamed := *qm
+	streamed.CacheDurationSeconds = 0
+	encoded, err := json.Marshal(&streamed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(refID+"/"), encoded...))
+	path := "sheet/" + hex.EncodeToString(sum[:8])
+
+	gs.streamsMu.Lock()
+	defer gs.streamsMu.Unlock()
+	if gs.streams == nil {
+		gs.streams = map[string]*streamQuery{}
+	}
+	now := time.Now()
+	for key, stream := range gs.streams {
+		if !stream.running && now.Sub(stream.registered) > streamRegistrationTTL {
+			delete(gs.streams, key)
+		}
+	}
+	key := streamKey(uid, path)
+	if stream, ok := gs.streams[key]; ok {
+		// Every refresh registers the query again, the path already stands for the same query
+		stream.registered = now
+		return path, nil
+	}
+	gs.streams[key] = &streamQuery{refID: refID, qm: &streamed, registered: now}
+	return path, nil
+}
+
+// HasStream tells if a stream path belongs to a query the datasource has run.
+func (gs *GoogleSheets) HasStream(uid string, path string) bool {
+	gs.streamsMu.Lock()
+	defer gs.streamsMu.Unlock()
+	_, ok := gs.streams[streamKey(uid, path)]
+	return ok
+}
+
+// StreamInterval is how often the query of a stream path is polled.
+func (gs *GoogleSheets) StreamInterval(uid string, path string) time.Duration {
+	gs.streamsMu.Lock()
+	defer gs.streamsMu.Unlock()
+	stream, ok := gs.streams[streamKey(uid, path)]
+	if !ok || stream.qm.StreamIntervalSeconds <= 0 {
+		return defaultStreamInterval
+	}
+	return time.Duration(stream.qm.StreamIntervalSeconds) * time.Second
+}
+
+// RunStream polls the range of a stream path on every tick and sends the rows appended since the last poll,
+// until ctx is cancelled. The stream is forgotten when it ends, running the query registers it again.
+func (gs *GoogleSheets) RunStream(ctx context.Context, path string, config *models.DatasourceSettings, ticks <-chan time.Time, send func(*data.Frame) error) error {
+	key := streamKey(config.UID, path)
+	gs.streamsMu.Lock()
+	stream, ok := gs.streams[key]
+	if ok {
+		stream.running = true
+	}
+	gs.streamsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("stream %q not found", path)
+	}
+	defer func() {
+		gs.streamsMu.Lock()
+		delete(gs.streams, key)
+		gs.streamsMu.Unlock()
+	}()
+
+	client, _, err := gs.newClient(ctx, config)
+	if err != nil {
+		return err
+	}
+	return gs.pollStream(ctx, client, stream.refID, stream.qm, ticks, send)
+}
+
+// pollStream checks the version of the spreadsheet on every tick and when it changed, reads the range and sends
+// a frame with only the rows below the last sheet row seen. Rows are told apart by their sheet row number, so
+// skipped or filtered rows don't shift the new ones. Streams assume append-style sheets: when rows are removed,
+// the last remaining row is only taken as the new baseline.
+func (gs *GoogleSheets) pollStream(ctx context.Context, client client, refID string, qm *models.QueryModel, ticks <-chan time.Time, send func(*data.Frame) error) error {
+	version, err := client.GetVersion(ctx, qm.Spreadsheet)
+	if err != nil {
+		return fmt.Errorf("unable to get the version of spreadsheet %q: %w", qm.Spreadsheet, err)
+	}
+	_, rowNumbers, err := gs.readStreamRows(ctx, client, refID, qm)
+	if err != nil {
+		return err
+	}
+	lastRow := maxRowNumber(rowNumbers)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
+
+		current, err := client.GetVersion(ctx, qm.Spreadsheet)
+		if err != nil {
+			// A failed poll is retried on the next tick
+			backend.Logger.Warn("Failed to poll streamed spreadsheet", "spreadsheet", qm.Spreadsheet, "error", err)
+			continue
+		}
+		if current == version {
+			continue
+		}
+
+		frame, rowNumbers, err := gs.readStreamRows(ctx, client, refID, qm)
+		if err != nil {
+			backend.Logger.Warn("Failed to read streamed range", "range", qm.Range, "error", err)
+			continue
+		}
+		version = current
+		indexes := []int{}
+		for i, rowNumber := range rowNumbers {
+			if rowNumber > lastRow {
+				indexes = append(indexes, i)
+			}
+		}
+		lastRow = maxRowNumber(rowNumbers)
+		if len(indexes) == 0 {
+			continue
+		}
+		if err := send(sampleFrame(frame, indexes)); err != nil {
+			return err
+		}
+	}
+}
+
+// readStreamRows reads the range of a stream, returning the sheet row number of every row of the frame.
+func (gs *GoogleSheets) readStreamRows(ctx context.Context, client client, refID string, qm *models.QueryModel) (*data.Frame, []int, error) {
+	withRowRange := *qm
+	withRowRange.IncludeRowRange = true
+	frame, err := gs.querySheet(ctx, client, refID, &withRowRange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := frameFieldIndex(frame, rowRangeFieldName)
+	if index < 0 {
+		return nil, nil, fmt.Errorf("the sheet rows of streamed range %q are unknown", qm.Range)
+	}
+	rowNumbers := make([]int, frame.Rows())
+	for i := range rowNumbers {
+		if rowRange, ok := frame.Fields[index].ConcreteAt(i); ok {
+			rowNumbers[i], _ = rowRangeNumber(rowRange.(string))
+		}
+	}
+	if !qm.IncludeRowRange {
+		frame.Fields = append(frame.Fields[:index], frame.Fields[index+1:]...)
+	}
+	return frame, rowNumbers, nil
+}
+
+// maxRowNumber returns the last of the sheet row numbers, 0 if there are none.
+func maxRowNumber(rowNumbers []int) int {
+	last := 0
+	for _, rowNumber := range rowNumbers {
+		if rowNumber > last {
+			last = rowNumber
+		}
+	}
+	return last
+}
+
+// streamChannel is the Grafana Live channel of a stream path of the datasource.
+func streamChannel(config *models.DatasourceSettings, path string) string {
+	return "ds/" + config.UID + "/" + path
+}
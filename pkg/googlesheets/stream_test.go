@@ -0,0 +1,141 @@
+package googlesheets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestStream(t *testing.T) {
+	load := func(t *testing.T, rows func([]*sheets.RowData) []*sheets.RowData) *sheets.Spreadsheet {
+		spreadsheet, err := loadTestSheet("./testdata/mixed-data.json")
+		require.NoError(t, err)
+		grid := spreadsheet.Sheets[0].Data[0]
+		grid.RowData = rows(grid.RowData)
+		return spreadsheet
+	}
+	full := load(t, func(rows []*sheets.RowData) []*sheets.RowData { return rows })
+	rows := len(full.Sheets[0].Data[0].RowData)
+	start := load(t, func(rows []*sheets.RowData) []*sheets.RowData { return rows[:len(rows)-2] })
+	mid := load(t, func(rows []*sheets.RowData) []*sheets.RowData { return rows[:len(rows)-1] })
+	gap := load(t, func(rows []*sheets.RowData) []*sheets.RowData {
+		return append(append(rows[:len(rows)-2:len(rows)-2], &sheets.RowData{}), rows[len(rows)-1])
+	})
+	require.Equal(t, rows, len(gap.Sheets[0].Data[0].RowData))
+
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+	qm := &models.QueryModel{Spreadsheet: "someid", Stream: true}
+	expected, err := gsd.transformSheetToDataFrame(full.Sheets[0], make(map[string]interface{}), "A", withSpreadsheetLocation(qm, full))
+	require.NoError(t, err)
+
+	poll := func(client *fakeClient, qm *models.QueryModel) (chan time.Time, chan *data.Frame, func()) {
+		ticks := make(chan time.Time)
+		sent := make(chan *data.Frame)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- gsd.pollStream(ctx, client, "A", qm, ticks, func(frame *data.Frame) error {
+				sent <- frame
+				return nil
+			})
+		}()
+		return ticks, sent, func() {
+			cancel()
+			assert.NoError(t, <-done)
+		}
+	}
+
+	t.Run("appended rows are sent when the spreadsheet changed", func(t *testing.T) {
+		client := &fakeClient{
+			versions:  []int64{1, 1, 2, 3, 4},
+			responses: []*sheets.Spreadsheet{start, mid, start, full},
+		}
+		ticks, sent, stop := poll(client, qm)
+
+		ticks <- time.Now() // unchanged
+		ticks <- time.Now() // one row appended
+		frame := <-sent
+		require.Equal(t, 1, frame.Rows())
+		assert.Equal(t, expected.Fields[0].At(expected.Rows()-2), frame.Fields[0].At(0))
+		assert.Equal(t, len(expected.Fields), len(frame.Fields), "the row range is only used to find new rows")
+
+		ticks <- time.Now() // a row removed, only the baseline changes
+		ticks <- time.Now() // two rows appended
+		frame = <-sent
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, expected.Fields[0].At(expected.Rows()-1), frame.Fields[0].At(1))
+		assert.Equal(t, 4, client.getSpreadsheetCalls, "sheets are only read when the spreadsheet changed")
+		stop()
+	})
+
+	t.Run("skipped rows don't shift the appended rows", func(t *testing.T) {
+		client := &fakeClient{
+			versions:  []int64{1, 2},
+			responses: []*sheets.Spreadsheet{start, gap},
+		}
+		ticks, sent, stop := poll(client, &models.QueryModel{Spreadsheet: "someid", Stream: true, SkipEmptyRows: true})
+
+		ticks <- time.Now() // an empty row and a row with data appended
+		frame := <-sent
+		require.Equal(t, 1, frame.Rows())
+		assert.Equal(t, expected.Fields[0].At(expected.Rows()-1), frame.Fields[0].At(0))
+		stop()
+	})
+
+	t.Run("streamed queries are registered per datasource with their interval", func(t *testing.T) {
+		path, err := gsd.registerStream("abc", "A", &models.QueryModel{Spreadsheet: "someid", Stream: true, StreamIntervalSeconds: 30, CacheDurationSeconds: 300})
+		require.NoError(t, err)
+		assert.True(t, gsd.HasStream("abc", path))
+		assert.False(t, gsd.HasStream("other", path), "streams of other datasources are not visible")
+		assert.False(t, gsd.HasStream("abc", "sheet/unknown"))
+		assert.Equal(t, 30*time.Second, gsd.StreamInterval("abc", path))
+		assert.Equal(t, 0, gsd.streams[streamKey("abc", path)].qm.CacheDurationSeconds, "streams are not cached")
+		assert.Equal(t, "ds/abc/"+path, streamChannel(&models.DatasourceSettings{UID: "abc"}, path))
+
+		other, err := gsd.registerStream("abc", "B", &models.QueryModel{Spreadsheet: "someid", Stream: true})
+		require.NoError(t, err)
+		assert.Equal(t, defaultStreamInterval, gsd.StreamInterval("abc", other))
+	})
+
+	t.Run("streams are forgotten when they end", func(t *testing.T) {
+		path, err := gsd.registerStream("abc", "C", &models.QueryModel{Spreadsheet: "someid", Stream: true})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = gsd.RunStream(ctx, path, &models.DatasourceSettings{UID: "abc", AuthType: "key", APIKey: "secret"}, nil, nil)
+		assert.Error(t, err)
+		assert.False(t, gsd.HasStream("abc", path))
+	})
+
+	t.Run("streams nobody subscribed to expire", func(t *testing.T) {
+		path, err := gsd.registerStream("abc", "D", &models.QueryModel{Spreadsheet: "someid", Stream: true})
+		require.NoError(t, err)
+		gsd.streams[streamKey("abc", path)].registered = time.Now().Add(-streamRegistrationTTL - time.Second)
+
+		_, err = gsd.registerStream("abc", "E", &models.QueryModel{Spreadsheet: "someid", Stream: true})
+		require.NoError(t, err)
+		assert.False(t, gsd.HasStream("abc", path))
+	})
+
+	t.Run("streams of several ranges are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{Stream: true, SheetRanges: map[string]string{"Sheet1": "A1:B"}}))
+		assert.Error(t, validateQuery(&models.QueryModel{Stream: true, StreamIntervalSeconds: -1}))
+	})
+
+	t.Run("streams of reordered or reshaped rows are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{Stream: true, ReverseRows: true}))
+		assert.Error(t, validateQuery(&models.QueryModel{Stream: true, Sample: models.Sample{MaxRows: 10}}))
+		assert.Error(t, validateQuery(&models.QueryModel{Stream: true, GroupByColumn: "Region"}))
+		assert.NoError(t, validateQuery(&models.QueryModel{Stream: true, SkipEmptyRows: true}))
+	})
+}
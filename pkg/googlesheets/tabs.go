@@ -0,0 +1,225 @@
+package googlesheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	dateTemplate  = "{{date}}"
+	tabDateFormat = "2006-01-02"
+	// maxDateTabs limits how many tabs a single query can fan out to
+	maxDateTabs = 366
+)
+
+// dateTab is a single tab a date template range expands to.
+type dateTab struct {
+	date       string
+	sheetRange string
+}
+
+func isDateTemplateRange(sheetRange string) bool {
+	return strings.Contains(sheetRange, dateTemplate)
+}
+
+// expandDateTabs expands the {{date}} template of the range to one range per day in the tab date range.
+func expandDateTabs(qm *models.QueryModel) ([]dateTab, error) {
+	from, err := time.Parse(tabDateFormat, qm.TabDateRange.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tab date range start %q: %w", qm.TabDateRange.From, err)
+	}
+	to, err := time.Parse(tabDateFormat, qm.TabDateRange.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tab date range end %q: %w", qm.TabDateRange.To, err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("tab date range end %q is before start %q", qm.TabDateRange.To, qm.TabDateRange.From)
+	}
+
+	tabs := []dateTab{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if len(tabs) == maxDateTabs {
+			return nil, fmt.Errorf("tab date range spans more than %d days", maxDateTabs)
+		}
+		date := day.Format(tabDateFormat)
+		tabs = append(tabs, dateTab{
+			date:       date,
+			sheetRange: strings.ReplaceAll(qm.Range, dateTemplate, date),
+		})
+	}
+	return tabs, nil
+}
+
+// queryDateTabs reads every tab in the tab date range and concatenates them into a
+// single frame with a sheet_date field, or returns a frame named after each tab with FramePerSheet.
+// Missing tabs are skipped with a warning.
+func (gs *GoogleSheets) queryDateTabs(ctx context.Context, client client, refID string, qm *models.QueryModel, maxConcurrentFetches int) ([]*data.Frame, error) {
+	tabs, err := expandDateTabs(qm)
+	if err != nil {
+		return nil, err
+	}
+
+	tabFrames := make([]*data.Frame, len(tabs))
+	tabErrors := make([]error, len(tabs))
+	forEachConcurrently(len(tabs), maxConcurrentFetches, func(i int) {
+		tabQuery := *qm
+		tabQuery.Range = tabs[i].sheetRange
+		tabFrames[i], tabErrors[i] = gs.querySheet(ctx, client, refID, &tabQuery)
+	})
+
+	frames := []*data.Frame{}
+	warnings := []string{}
+	for i, tab := range tabs {
+		frame, err := tabFrames[i], tabErrors[i]
+		if err != nil {
+			if isMissingRangeError(err) {
+				warning := fmt.Sprintf("Skipping missing tab for date %s", tab.date)
+				warnings = append(warnings, warning)
+				backend.Logger.Warn(warning)
+				continue
+			}
+			return nil, err
+		}
+
+		if qm.FramePerSheet {
+			frame.Name = sheetNameOf(tab.sheetRange)
+			frames = append(frames, frame)
+			continue
+		}
+
+		dates := make([]string, frame.Rows())
+		for i := range dates {
+			dates[i] = tab.date
+		}
+		frame.Fields = append(frame.Fields, data.NewField("sheet_date", nil, dates))
+		warnings = append(warnings, frame.Meta.Custom.(map[string]interface{})["warnings"].([]string)...)
+		frames = append(frames, frame)
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no tabs found for dates %s to %s", qm.TabDateRange.From, qm.TabDateRange.To)
+	}
+
+	if qm.FramePerSheet {
+		// Each frame keeps its own meta, the missing tabs are reported on the first one
+		meta := frames[0].Meta.Custom.(map[string]interface{})
+		meta["warnings"] = append(warnings, meta["warnings"].([]string)...)
+		return frames, nil
+	}
+
+	frame, err := concatFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{
+		"warnings":      warnings,
+		"spreadsheetId": qm.Spreadsheet,
+		"range":         qm.Range,
+	}}
+	return []*data.Frame{frame}, nil
+}
+
+// concatFrames appends the rows of all frames to the first one. All frames must have the same fields.
+func concatFrames(frames []*data.Frame) (*data.Frame, error) {
+	result := frames[0]
+	for _, frame := range frames[1:] {
+		if len(frame.Fields) != len(result.Fields) {
+			return nil, fmt.Errorf("cannot combine frames with %d and %d fields", len(result.Fields), len(frame.Fields))
+		}
+		for i, field := range frame.Fields {
+			if field.Name != result.Fields[i].Name || field.Type() != result.Fields[i].Type() {
+				return nil, fmt.Errorf("cannot combine field %q of type %s with field %q of type %s",
+					result.Fields[i].Name, result.Fields[i].Type(), field.Name, field.Type())
+			}
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			result.AppendRow(frame.RowCopy(row)...)
+		}
+	}
+	return result, nil
+}
+
+// isMissingRangeError checks if the Sheets API rejected the range, e.g. because the tab does not exist.
+func isMissingRangeError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "Unable to parse range")
+}
+
+// sheetRangeList returns the ranges of SheetRanges prefixed with their tab, sorted by tab.
+// An empty range reads the whole tab.
+func sheetRangeList(sheetRanges map[string]string) []string {
+	tabs := make([]string, 0, len(sheetRanges))
+	for tab := range sheetRanges {
+		tabs = append(tabs, tab)
+	}
+	sort.Strings(tabs)
+
+	ranges := make([]string, len(tabs))
+	for i, tab := range tabs {
+		ranges[i] = quoteSheetName(tab)
+		if sheetRanges[tab] != "" {
+			ranges[i] += "!" + sheetRanges[tab]
+		}
+	}
+	return ranges
+}
+
+// querySheetRanges reads the range of every tab in SheetRanges in a single request and returns a frame named
+// after each tab, in sheet order. Tabs that are not listed are skipped.
+func (gs *GoogleSheets) querySheetRanges(ctx context.Context, client client, refID string, qm *models.QueryModel) ([]*data.Frame, error) {
+	release, err := gs.acquireTransformSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	spreadsheet, meta, err := gs.getSheetData(ctx, client, qm)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []*data.Frame{}
+	for _, sheet := range spreadsheet.Sheets {
+		title := sheet.Properties.Title
+		sheetRange, ok := qm.SheetRanges[title]
+		if !ok {
+			continue
+		}
+
+		tabQuery := *qm
+		tabQuery.Range = quoteSheetName(title)
+		if sheetRange != "" {
+			tabQuery.Range += "!" + sheetRange
+		}
+		tabQuery.SheetRanges = nil
+		tabQuery.Location = spreadsheetLocation(spreadsheet)
+		tabMeta := make(map[string]interface{}, len(meta))
+		for key, value := range meta {
+			tabMeta[key] = value
+		}
+
+		frame, err := gs.transformSheetToDataFrame(sheet, tabMeta, refID, &tabQuery)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tab %q: %w", title, err)
+		}
+		if spreadsheet.Properties != nil {
+			applyDefaultFormat(frame, spreadsheet.Properties.DefaultFormat)
+		}
+		frame.Name = title
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
@@ -0,0 +1,210 @@
+package googlesheets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/sheets/v4"
+)
+
+func loadDateTabs(t *testing.T) map[string]*sheets.Spreadsheet {
+	spreadsheet, err := loadTestSheet("./testdata/date-tabs.json")
+	require.NoError(t, err)
+
+	byRange := map[string]*sheets.Spreadsheet{}
+	for _, sheet := range spreadsheet.Sheets {
+		byRange[sheet.Properties.Title+"!A1:B"] = &sheets.Spreadsheet{
+			Properties: spreadsheet.Properties,
+			Sheets:     []*sheets.Sheet{sheet},
+		}
+	}
+	return byRange
+}
+
+func TestDateTabs(t *testing.T) {
+	t.Run("a three day range expands to three tabs", func(t *testing.T) {
+		client := &fakeClient{sheetsByRange: loadDateTabs(t)}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{
+			Spreadsheet:  "someid",
+			Range:        "{{date}}!A1:B",
+			TabDateRange: models.DateRange{From: "2024-01-15", To: "2024-01-17"},
+		}
+
+		frames, err := gsd.queryDateTabs(context.Background(), client, "ref1", &qm, 1)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, []string{"2024-01-15!A1:B", "2024-01-16!A1:B", "2024-01-17!A1:B"}, client.requestedRanges)
+
+		require.Equal(t, 3, len(frame.Fields))
+		assert.Equal(t, "sheet_date", frame.Fields[2].Name)
+		require.Equal(t, 6, frame.Rows())
+		dates := []string{}
+		for i := 0; i < frame.Rows(); i++ {
+			dates = append(dates, frame.Fields[2].At(i).(string))
+		}
+		assert.Equal(t, []string{"2024-01-15", "2024-01-15", "2024-01-16", "2024-01-17", "2024-01-17", "2024-01-17"}, dates)
+		assert.Equal(t, 6.0, *frame.Fields[1].At(5).(*float64))
+	})
+
+	t.Run("missing tabs are skipped with a warning", func(t *testing.T) {
+		tabs := loadDateTabs(t)
+		delete(tabs, "2024-01-16!A1:B")
+		client := &fakeClient{sheetsByRange: tabs}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{
+			Spreadsheet:  "someid",
+			Range:        "{{date}}!A1:B",
+			TabDateRange: models.DateRange{From: "2024-01-14", To: "2024-01-17"},
+		}
+
+		frames, err := gsd.queryDateTabs(context.Background(), client, "ref1", &qm, 1)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, 5, frame.Rows())
+		warnings := frame.Meta.Custom.(map[string]interface{})["warnings"].([]string)
+		assert.Equal(t, []string{"Skipping missing tab for date 2024-01-14", "Skipping missing tab for date 2024-01-16"}, warnings)
+	})
+
+	t.Run("every tab becomes a frame named after it with FramePerSheet", func(t *testing.T) {
+		tabs := loadDateTabs(t)
+		delete(tabs, "2024-01-16!A1:B")
+		client := &fakeClient{sheetsByRange: tabs}
+		gsd := &GoogleSheets{
+			Cache: cache.New(300*time.Second, 50*time.Second),
+		}
+		qm := models.QueryModel{
+			Spreadsheet:   "someid",
+			Range:         "{{date}}!A1:B",
+			TabDateRange:  models.DateRange{From: "2024-01-15", To: "2024-01-17"},
+			FramePerSheet: true,
+		}
+
+		frames, err := gsd.queryDateTabs(context.Background(), client, "ref1", &qm, 1)
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+		assert.Equal(t, "2024-01-15", frames[0].Name)
+		assert.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, "2024-01-17", frames[1].Name)
+		assert.Equal(t, 3, frames[1].Rows())
+		for _, frame := range frames {
+			assert.Equal(t, "ref1", frame.RefID)
+			assert.Equal(t, 2, len(frame.Fields))
+		}
+		warnings := frames[0].Meta.Custom.(map[string]interface{})["warnings"].([]string)
+		assert.Equal(t, []string{"Skipping missing tab for date 2024-01-16"}, warnings)
+	})
+
+	t.Run("invalid tab date ranges are rejected", func(t *testing.T) {
+		qm := models.QueryModel{Range: "{{date}}!A1:B", TabDateRange: models.DateRange{From: "2024-01-17", To: "2024-01-15"}}
+		assert.Error(t, validateQuery(&qm))
+
+		qm.TabDateRange = models.DateRange{From: "15/01/2024", To: "2024-01-15"}
+		assert.Error(t, validateQuery(&qm))
+
+		qm.TabDateRange = models.DateRange{From: "2020-01-01", To: "2024-01-15"}
+		assert.Error(t, validateQuery(&qm))
+	})
+}
+
+// countingClient tracks how many GetSpreadsheet calls are running at the same time.
+type countingClient struct {
+	*fakeClient
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *countingClient) GetSpreadsheet(ctx context.Context, spreadSheetID string, sheetRange string, includeGridData bool, fields string) (*sheets.Spreadsheet, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	return c.fakeClient.GetSpreadsheet(ctx, spreadSheetID, sheetRange, includeGridData, fields)
+}
+
+func TestMaxConcurrentFetches(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/date-tabs.json")
+	require.NoError(t, err)
+	byRange := map[string]*sheets.Spreadsheet{}
+	for day := 1; day <= 9; day++ {
+		byRange[fmt.Sprintf("2024-01-0%d!A1:B", day)] = &sheets.Spreadsheet{
+			Properties: spreadsheet.Properties,
+			Sheets:     []*sheets.Sheet{spreadsheet.Sheets[0]},
+		}
+	}
+
+	for _, limit := range []int{1, 3} {
+		t.Run(fmt.Sprintf("no more than %d concurrent fetches", limit), func(t *testing.T) {
+			client := &countingClient{fakeClient: &fakeClient{sheetsByRange: byRange}}
+			gsd := &GoogleSheets{
+				Cache: cache.New(300*time.Second, 50*time.Second),
+			}
+			qm := models.QueryModel{
+				Spreadsheet:  "someid",
+				Range:        "{{date}}!A1:B",
+				TabDateRange: models.DateRange{From: "2024-01-01", To: "2024-01-09"},
+			}
+
+			frames, err := gsd.queryDateTabs(context.Background(), client, "ref1", &qm, limit)
+			require.NoError(t, err)
+			assert.Equal(t, 18, frames[0].Rows())
+			assert.Equal(t, 9, client.getSpreadsheetCalls)
+			assert.Equal(t, limit, client.maxInFlight)
+		})
+	}
+}
+
+func TestSheetRanges(t *testing.T) {
+	spreadsheet, err := loadTestSheet("./testdata/sheet-ranges.json")
+	require.NoError(t, err)
+	client := &fakeClient{sheetsByRange: map[string]*sheets.Spreadsheet{
+		"'Costs'!A1:C,'Sales'!B2:C4": spreadsheet,
+	}}
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	qm := models.QueryModel{Spreadsheet: "someid", SheetRanges: map[string]string{"Sales": "B2:C4", "Costs": "A1:C"}}
+	frames, err := gsd.querySheetRanges(context.Background(), client, "A", &qm)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.getSpreadsheetCalls, "the tabs are read in one request")
+
+	require.Equal(t, 2, len(frames), "tabs that are not listed are skipped")
+	sales, costs := frames[0], frames[1]
+	assert.Equal(t, "Sales", sales.Name)
+	assert.Equal(t, "Region", sales.Fields[0].Name)
+	assert.Equal(t, 2, sales.Rows())
+	assert.Equal(t, 120.0, *sales.Fields[1].At(0).(*float64))
+	assert.Equal(t, "'Sales'!B2:C4", sales.Meta.Custom.(map[string]interface{})["range"])
+
+	assert.Equal(t, "Costs", costs.Name)
+	assert.Equal(t, 3, len(costs.Fields))
+	assert.Equal(t, "Ops", *costs.Fields[2].At(0).(*string))
+	assert.Equal(t, "'Costs'!A1:C", costs.Meta.Custom.(map[string]interface{})["range"])
+
+	t.Run("sheet ranges replace the range", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{Range: "A1:B", SheetRanges: map[string]string{"Sales": "A1:B"}}))
+	})
+}
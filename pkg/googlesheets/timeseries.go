@@ -0,0 +1,96 @@
+package googlesheets
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// timeSeriesColumns returns the time column of a wide time series: the TimeColumn when set, otherwise the first
+// column detected as time. The other columns with number cells are forced to numbers, unless their type is
+// already set, so that a stray text cell is empty with a warning rather than turning the series into strings.
+// The other time columns are returned too, to be left out of the series.
+func timeSeriesColumns(columns []*ColumnDefinition, timeColumn string) (string, map[string]bool, error) {
+	if timeColumn == "" {
+		for _, column := range columns {
+			if column.GetType() == ColumTypeTime {
+				timeColumn = column.Header
+				break
+			}
+		}
+		if timeColumn == "" {
+			return "", nil, fmt.Errorf("no time column found for the wide time series, set the time column")
+		}
+	}
+
+	otherTimeColumns := map[string]bool{}
+	for _, column := range columns {
+		if column.Header == timeColumn {
+			continue
+		}
+		if column.forcedType == "" && column.types[ColumTypeNumber] {
+			column.ForceType(ColumTypeNumber)
+		}
+		if column.GetType() == ColumTypeTime {
+			otherTimeColumns[column.Header] = true
+		}
+	}
+	return timeColumn, otherTimeColumns, nil
+}
+
+// wideTimeSeriesFrame returns a frame with the time field followed by the number fields, one series each, and
+// the rows sorted by time. Other fields, and the fields of the other time columns that are numbers as epoch
+// milliseconds, are dropped with a warning. Rows without a time are sorted last.
+func wideTimeSeriesFrame(frame *data.Frame, timeColumn string, otherTimeColumns map[string]bool) (*data.Frame, []string, error) {
+	index := frameFieldIndex(frame, timeColumn)
+	if index < 0 {
+		return nil, nil, fmt.Errorf("time column %q not found", timeColumn)
+	}
+
+	wide := data.NewFrame(frame.Name, frame.Fields[index])
+	wide.RefID = frame.RefID
+	warnings := []string{}
+	for i, field := range frame.Fields {
+		if i == index {
+			continue
+		}
+		if otherTimeColumns[field.Name] {
+			warnings = append(warnings, fmt.Sprintf("Dropped column %q from the wide time series, it is a time column", field.Name))
+			continue
+		}
+		if !field.Type().Numeric() {
+			warnings = append(warnings, fmt.Sprintf("Dropped column %q from the wide time series, it is not a number column", field.Name))
+			continue
+		}
+		wide.Fields = append(wide.Fields, field)
+	}
+
+	times := make([]*time.Time, wide.Rows())
+	for i := range times {
+		switch value := wide.Fields[0].At(i).(type) {
+		case *time.Time:
+			times[i] = value
+		case *int64:
+			if value != nil {
+				t := time.Unix(0, *value*int64(time.Millisecond))
+				times[i] = &t
+			}
+		default:
+			return nil, nil, fmt.Errorf("time column %q is not a time column", timeColumn)
+		}
+	}
+	indexes := make([]int, len(times))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		ta, tb := times[indexes[a]], times[indexes[b]]
+		if ta == nil || tb == nil {
+			return ta != nil
+		}
+		return ta.Before(*tb)
+	})
+	return sampleFrame(wide, indexes), warnings, nil
+}
@@ -0,0 +1,87 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeriesWide(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/sensor-readings.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("number columns become series sorted by time", func(t *testing.T) {
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TimeSeriesWide: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, "Time", frame.Fields[0].Name)
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		for i, name := range []string{"Temperature", "Humidity", "Pressure"} {
+			assert.Equal(t, name, frame.Fields[i+1].Name)
+			assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[i+1].Type())
+		}
+		assert.Equal(t, data.TimeSeriesTypeWide, frame.TimeSeriesSchema().Type)
+
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, 0, frame.Fields[0].At(0).(*time.Time).Hour())
+		assert.Equal(t, 6, frame.Fields[0].At(1).(*time.Time).Hour())
+		assert.Equal(t, 12, frame.Fields[0].At(2).(*time.Time).Hour())
+		assert.Equal(t, []float64{18, 19.25, 21.5}, []float64{
+			*frame.Fields[1].At(0).(*float64), *frame.Fields[1].At(1).(*float64), *frame.Fields[1].At(2).(*float64),
+		})
+		assert.Equal(t, 1011.0, *frame.Fields[3].At(1).(*float64))
+		assert.Contains(t, meta["warnings"], `Dropped column "Location" from the wide time series, it is not a number column`)
+	})
+
+	t.Run("text cells of number columns are empty", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/sensor-gaps.json")
+		require.NoError(t, err)
+		meta := make(map[string]interface{})
+		qm := models.QueryModel{Spreadsheet: "someid", TimeSeriesWide: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], meta, "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 2)
+		temperature := frame.Fields[1]
+		assert.Equal(t, "Temperature", temperature.Name)
+		require.Equal(t, data.FieldTypeNullableFloat64, temperature.Type())
+		assert.Nil(t, temperature.At(0), "the n/a reading of midnight")
+		assert.Equal(t, 19.25, *temperature.At(1).(*float64))
+		assert.Contains(t, meta["warnings"], "expected numeric value but got 'n/a'")
+		assert.Contains(t, meta["warnings"], `Dropped column "Location" from the wide time series, it is not a number column`)
+		assert.Contains(t, meta["warnings"], `Dropped column "Calibrated" from the wide time series, it is a time column`)
+	})
+
+	t.Run("other time columns are not series as epoch milliseconds", func(t *testing.T) {
+		sheet, err := loadTestSheet("./testdata/sensor-gaps.json")
+		require.NoError(t, err)
+		qm := models.QueryModel{Spreadsheet: "someid", TimeSeriesWide: true, TimeAsEpochMillis: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 2)
+		assert.Equal(t, data.FieldTypeNullableInt64, frame.Fields[0].Type())
+		assert.Equal(t, "Temperature", frame.Fields[1].Name)
+	})
+
+	t.Run("a time column is required", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", TimeSeriesWide: true, ColumnTypes: map[string]string{"Time": "string"}}
+		_, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		assert.EqualError(t, err, "no time column found for the wide time series, set the time column")
+	})
+
+	t.Run("group by and pivot are rejected", func(t *testing.T) {
+		assert.Error(t, validateQuery(&models.QueryModel{TimeSeriesWide: true, GroupByColumn: "Location"}))
+	})
+}
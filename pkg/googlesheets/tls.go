@@ -0,0 +1,45 @@
+package googlesheets
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+)
+
+// newTLSConfig returns the TLS config presenting the configured client certificate,
+// or nil when no certificate is configured.
+func newTLSConfig(auth *models.DatasourceSettings) (*tls.Config, error) {
+	if len(auth.TLSClientCert) == 0 && len(auth.TLSClientKey) == 0 && len(auth.TLSCACert) == 0 {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(auth.TLSClientCert) > 0 || len(auth.TLSClientKey) > 0 {
+		certificate, err := tls.X509KeyPair([]byte(auth.TLSClientCert), []byte(auth.TLSClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS client certificate or key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if len(auth.TLSCACert) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(auth.TLSCACert)) {
+			return nil, fmt.Errorf("invalid TLS CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// ValidateTLSSettings checks that the configured client certificate, key and CA certificate can be parsed.
+func ValidateTLSSettings(auth *models.DatasourceSettings) error {
+	_, err := newTLSConfig(auth)
+	return err
+}
@@ -0,0 +1,35 @@
+package googlesheets
+
+import "google.golang.org/api/sheets/v4"
+
+// transposeGrid swaps the rows and columns of the grid data, for sheets with one record per column and the
+// field names in the first column. Column metadata becomes row metadata, so hidden columns are hidden records.
+func transposeGrid(grid *sheets.GridData) *sheets.GridData {
+	columnCount := 0
+	for _, row := range grid.RowData {
+		if len(row.Values) > columnCount {
+			columnCount = len(row.Values)
+		}
+	}
+
+	rows := make([]*sheets.RowData, columnCount)
+	for i := range rows {
+		values := make([]*sheets.CellData, len(grid.RowData))
+		for j, row := range grid.RowData {
+			if i < len(row.Values) {
+				values[j] = row.Values[i]
+			} else {
+				values[j] = &sheets.CellData{}
+			}
+		}
+		rows[i] = &sheets.RowData{Values: values}
+	}
+
+	return &sheets.GridData{
+		RowData:        rows,
+		RowMetadata:    grid.ColumnMetadata,
+		ColumnMetadata: grid.RowMetadata,
+		StartRow:       grid.StartColumn,
+		StartColumn:    grid.StartRow,
+	}
+}
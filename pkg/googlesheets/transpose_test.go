@@ -0,0 +1,45 @@
+package googlesheets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransposed(t *testing.T) {
+	sheet, err := loadTestSheet("./testdata/transposed.json")
+	require.NoError(t, err)
+	gsd := &GoogleSheets{
+		Cache: cache.New(300*time.Second, 50*time.Second),
+	}
+
+	t.Run("the first column holds the field names and every other column is a record", func(t *testing.T) {
+		qm := models.QueryModel{Spreadsheet: "someid", Transposed: true}
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &qm)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, len(frame.Fields))
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "Name", frame.Fields[0].Name)
+		assert.Equal(t, "Score", frame.Fields[1].Name)
+		assert.Equal(t, "Active", frame.Fields[2].Name)
+
+		assert.Equal(t, "Beta", *frame.Fields[0].At(1).(*string))
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		assert.Equal(t, 30.0, *frame.Fields[1].At(2).(*float64))
+		assert.Equal(t, "FALSE", *frame.Fields[2].At(1).(*string))
+		assert.Nil(t, frame.Fields[2].At(2), "short rows are padded with empty cells")
+	})
+
+	t.Run("the first row holds the field names by default", func(t *testing.T) {
+		frame, err := gsd.transformSheetToDataFrame(sheet.Sheets[0], make(map[string]interface{}), "ref1", &models.QueryModel{Spreadsheet: "someid"})
+		require.NoError(t, err)
+		assert.Equal(t, "Alpha", frame.Fields[1].Name)
+		assert.Equal(t, 2, frame.Rows())
+	})
+}
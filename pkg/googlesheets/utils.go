@@ -1,7 +1,17 @@
 package googlesheets
 
 import (
+	crand "crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/google-sheets-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/api/sheets/v4"
 )
 
 func findTimeField(frame *data.Frame) int {
@@ -12,6 +22,164 @@ func findTimeField(frame *data.Frame) int {
 	return timeIndices[0]
 }
 
+// findColumn returns the column with the given header, or nil if there is none.
+func findColumn(columns []*ColumnDefinition, header string) *ColumnDefinition {
+	for _, column := range columns {
+		if column.Header == header {
+			return column
+		}
+	}
+	return nil
+}
+
+// shiftTimeFields adds offset to every value of the time columns, including ones emitted as epoch milliseconds.
+func shiftTimeFields(frame *data.Frame, columns []*ColumnDefinition, offset time.Duration) {
+	for i, column := range columns {
+		if column.GetType() != ColumTypeTime {
+			continue
+		}
+
+		field := frame.Fields[i]
+		for row := 0; row < field.Len(); row++ {
+			switch value := field.At(row).(type) {
+			case *time.Time:
+				if value != nil {
+					shifted := value.Add(offset)
+					field.Set(row, &shifted)
+				}
+			case *int64:
+				if value != nil {
+					shifted := *value + offset.Milliseconds()
+					field.Set(row, &shifted)
+				}
+			}
+		}
+	}
+}
+
+// scaleNumberFields multiplies the values of numeric columns by their scale and
+// returns a warning for every scaled column that is not numeric.
+func scaleNumberFields(frame *data.Frame, columns []*ColumnDefinition, scale map[string]float64) []string {
+	warnings := []string{}
+	for i, column := range columns {
+		multiplier, ok := scale[column.Header]
+		if !ok {
+			continue
+		}
+
+		field := frame.Fields[i]
+		if field.Type() != data.FieldTypeNullableFloat64 {
+			warnings = append(warnings, fmt.Sprintf("Column %q is not numeric and can not be scaled", column.Header))
+			continue
+		}
+		for row := 0; row < field.Len(); row++ {
+			if value := field.At(row).(*float64); value != nil {
+				scaled := *value * multiplier
+				field.Set(row, &scaled)
+			}
+		}
+	}
+	return warnings
+}
+
+// truncateStringFields shortens string values longer than maxLength characters, marking the cut
+// with an ellipsis. It returns how many values were truncated.
+func truncateStringFields(frame *data.Frame, maxLength int) int {
+	truncated := 0
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableString {
+			continue
+		}
+		for row := 0; row < field.Len(); row++ {
+			value, ok := field.At(row).(*string)
+			if !ok || value == nil {
+				continue
+			}
+			runes := []rune(*value)
+			if len(runes) <= maxLength {
+				continue
+			}
+			shortened := string(runes[:maxLength]) + "…"
+			field.Set(row, &shortened)
+			truncated++
+		}
+	}
+	return truncated
+}
+
+// setFieldRanges sets the config min and max of every number field to its smallest and largest value.
+// Fields without any values keep their config.
+func setFieldRanges(frame *data.Frame) {
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableFloat64 && field.Type() != data.FieldTypeFloat64 {
+			continue
+		}
+
+		found := false
+		var min, max float64
+		for i := 0; i < field.Len(); i++ {
+			value, ok := field.ConcreteAt(i)
+			if !ok || math.IsNaN(value.(float64)) {
+				continue
+			}
+			v := value.(float64)
+			if !found || v < min {
+				min = v
+			}
+			if !found || v > max {
+				max = v
+			}
+			found = true
+		}
+		if !found {
+			continue
+		}
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+		field.Config.SetMin(min).SetMax(max)
+	}
+}
+
+// jitterDuration randomly shortens or lengthens d by up to the jitter fraction, e.g. 0.1 for ±10%.
+func jitterDuration(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return d + time.Duration((2*rand.Float64()-1)*jitter*float64(d))
+}
+
+// sizeCacheDuration multiplies d by the tier with the most cells that cellCount reaches, so large sheets that are
+// expensive to fetch stay cached longer. Without a matching tier d is returned as is.
+func sizeCacheDuration(d time.Duration, cellCount int, tiers []models.CacheSizeTier) time.Duration {
+	var tier *models.CacheSizeTier
+	for i := range tiers {
+		if cellCount >= tiers[i].MinCells && (tier == nil || tiers[i].MinCells > tier.MinCells) {
+			tier = &tiers[i]
+		}
+	}
+	if tier == nil || tier.Multiplier <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * tier.Multiplier)
+}
+
+// countCells counts the cells in the grid data of the spreadsheet.
+func countCells(spreadsheet *sheets.Spreadsheet) int {
+	count := 0
+	for _, sheet := range spreadsheet.Sheets {
+		for _, grid := range sheet.Data {
+			for _, row := range grid.RowData {
+				count += len(row.Values)
+			}
+		}
+	}
+	return count
+}
+
 func getExcelColumnName(columnNumber int) string {
 	dividend := columnNumber
 	columnName := ""
@@ -25,3 +193,55 @@ func getExcelColumnName(columnNumber int) string {
 
 	return columnName
 }
+
+// gridRangeToA1 formats a grid range as A1 notation on the quoted sheet title. Missing end indexes mean the range is unbounded.
+func gridRangeToA1(sheetTitle string, gridRange *sheets.GridRange) string {
+	rowsBounded := gridRange.EndRowIndex > 0
+	columnsBounded := gridRange.EndColumnIndex > 0
+
+	var start, end string
+	switch {
+	case rowsBounded && columnsBounded:
+		start = fmt.Sprintf("%s%d", getExcelColumnName(int(gridRange.StartColumnIndex)+1), gridRange.StartRowIndex+1)
+		end = fmt.Sprintf("%s%d", getExcelColumnName(int(gridRange.EndColumnIndex)), gridRange.EndRowIndex)
+	case columnsBounded:
+		start = getExcelColumnName(int(gridRange.StartColumnIndex) + 1)
+		end = getExcelColumnName(int(gridRange.EndColumnIndex))
+	case rowsBounded:
+		start = fmt.Sprintf("%d", gridRange.StartRowIndex+1)
+		end = fmt.Sprintf("%d", gridRange.EndRowIndex)
+	default:
+		return quoteSheetName(sheetTitle)
+	}
+
+	return fmt.Sprintf("%s!%s:%s", quoteSheetName(sheetTitle), start, end)
+}
+
+// newSheetID picks a random id for a sheet added by a batchUpdate, so later requests of the same batch can refer to it.
+func newSheetID() (int64, error) {
+	n, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt32))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64() + 1, nil
+}
+
+// spreadsheetLocation is the time zone of a spreadsheet, or nil when it has none or it is unknown.
+func spreadsheetLocation(spreadsheet *sheets.Spreadsheet) *time.Location {
+	if spreadsheet.Properties == nil || spreadsheet.Properties.TimeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(spreadsheet.Properties.TimeZone)
+	if err != nil {
+		backend.Logger.Warn("Could not load time zone of spreadsheet", "timeZone", spreadsheet.Properties.TimeZone, "error", err)
+		return nil
+	}
+	return loc
+}
+
+// withSpreadsheetLocation returns a copy of the query that parses times in the time zone of the spreadsheet.
+func withSpreadsheetLocation(qm *models.QueryModel, spreadsheet *sheets.Spreadsheet) *models.QueryModel {
+	located := *qm
+	located.Location = spreadsheetLocation(spreadsheet)
+	return &located
+}
@@ -19,6 +19,7 @@ func main() {
 		CallResourceHandler: httpResourceHandler,
 		QueryDataHandler:    ds,
 		CheckHealthHandler:  ds,
+		StreamHandler:       ds,
 	})
 	if err != nil {
 		backend.Logger.Error(err.Error())
@@ -3,20 +3,232 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+const (
+	// QueryTypeTestAPI checks every capability the datasource needs.
+	QueryTypeTestAPI = "testAPI"
+	// QueryTypeConditionalFormats lists the conditional format rules of the spreadsheet.
+	QueryTypeConditionalFormats = "conditionalFormats"
+	// QueryTypeCellTypes returns the data together with the Sheets value type of every cell.
+	QueryTypeCellTypes = "cellTypes"
+	// QueryTypeBatchUpdate forwards a raw batchUpdate request to the Sheets API.
+	QueryTypeBatchUpdate = "batchUpdate"
+	// QueryTypeValidateCredentials checks a service account key without reading any sheet.
+	QueryTypeValidateCredentials = "validateCredentials"
+	// QueryTypeListNamedRanges lists the named ranges of the spreadsheet.
+	QueryTypeListNamedRanges = "listNamedRanges"
+	// QueryTypeListSheets lists the sheets of the spreadsheet with their properties.
+	QueryTypeListSheets = "listSheets"
+	// QueryTypeSnapshot reads the range and writes the result to a new, timestamped sheet.
+	QueryTypeSnapshot = "snapshot"
+	// QueryTypeListCharts lists the charts of the spreadsheet.
+	QueryTypeListCharts = "listCharts"
+)
+
 // QueryModel represents a spreadsheet query.
 type QueryModel struct {
 	Spreadsheet          string `json:"spreadsheet"`
 	Range                string `json:"range"`
 	CacheDurationSeconds int    `json:"cacheDurationSeconds"`
 	UseTimeFilter        bool   `json:"useTimeFilter"`
+	LabelColumn          string `json:"labelColumn"`
+	RefreshOnGrowth      bool   `json:"refreshOnGrowth"`
+	FrameName            string `json:"frameName"`
+	// ExtractImages adds the urls of IMAGE() formulas as <column>_image fields. Images inserted into a cell
+	// through the Sheets UI are not available through the API, their cells are empty.
+	ExtractImages      bool   `json:"extractImages"`
+	WaitForConsistency bool   `json:"waitForConsistency"`
+	AnchorCellValue    string `json:"anchorCellValue"`
+	AnchorRowOffset    int    `json:"anchorRowOffset"`
+	AnchorColumnOffset int    `json:"anchorColumnOffset"`
+	// RegexFilter maps a column header to a regular expression its values must match
+	RegexFilter      map[string]string `json:"regexFilter"`
+	CollectRowErrors bool              `json:"collectRowErrors"`
+	TrimStrings      bool              `json:"trimStrings"`
+	// UseFrozenRowsAsHeader uses the frozen rows of the sheet as the header rows
+	UseFrozenRowsAsHeader bool `json:"useFrozenRowsAsHeader"`
+	TimeAsEpochMillis     bool `json:"timeAsEpochMillis"`
+	// TabDateRange is the range of dates a {{date}} template in Range expands to
+	TabDateRange DateRange `json:"tabDateRange"`
+	// TimeOffset is a duration, e.g. "-5h", added to every time value
+	TimeOffset string `json:"timeOffset"`
+	// ColumnIncludeGlob and ColumnExcludeGlob select columns by header, e.g. "metric_*"
+	ColumnIncludeGlob string `json:"columnIncludeGlob"`
+	ColumnExcludeGlob string `json:"columnExcludeGlob"`
+	// RowHeaderColumn reads a matrix with the row identifiers in column A and the series names in row 1
+	RowHeaderColumn bool `json:"rowHeaderColumn"`
+	// TypeDetectionSampleSize limits column type detection to the first N rows, 0 uses every row
+	TypeDetectionSampleSize int `json:"typeDetectionSampleSize"`
+	// BatchUpdateRequest is the raw Sheets API batchUpdate request of the batchUpdate query type
+	BatchUpdateRequest json.RawMessage `json:"batchUpdateRequest"`
+	// ColumnTypes maps a column header to the type (time, number or string) it is read as
+	ColumnTypes map[string]string `json:"columnTypes"`
+	// SchemaRange points at a two-column (name, type) region that column types are read from
+	SchemaRange string `json:"schemaRange"`
+	// Scale maps a column header to a multiplier applied to its numeric values
+	Scale map[string]float64 `json:"scale"`
+	// DuplicateSuffixFormat names duplicate headers, e.g. "{name} ({n})" or "{name}_{n}"
+	DuplicateSuffixFormat string `json:"duplicateSuffixFormat"`
+	// EpochSecondsColumns and EpochMillisColumns are numeric columns holding Unix timestamps
+	EpochSecondsColumns []string `json:"epochSecondsColumns"`
+	EpochMillisColumns  []string `json:"epochMillisColumns"`
+	// Sample keeps rows spread evenly across the data, e.g. for previews
+	Sample Sample `json:"sample"`
+	// RespectHiddenRows drops the rows hidden by filters or slicers in the sheet
+	RespectHiddenRows bool `json:"respectHiddenRows"`
+	// PivotColumn turns its distinct values into fields holding PivotValueColumn, one row per PivotIndexColumns combination
+	PivotColumn       string   `json:"pivotColumn"`
+	PivotValueColumn  string   `json:"pivotValueColumn"`
+	PivotIndexColumns []string `json:"pivotIndexColumns"`
+	// Credentials is the service account JSON key checked by the validateCredentials query type, before it is saved
+	Credentials string `json:"credentials"`
+	// JoinRange is left joined onto the data on the JoinKeys columns
+	JoinRange string   `json:"joinRange"`
+	JoinKeys  []string `json:"joinKeys"`
+	// NullTokens are values such as "N/A" that are read as empty cells, ignoring case and whitespace
+	NullTokens []string `json:"nullTokens"`
+	// ReverseRows returns the data rows in reverse sheet order, e.g. newest first for append-only logs
+	ReverseRows bool `json:"reverseRows"`
+	// TimeColumn is read as the time field regardless of the detected type
+	TimeColumn string `json:"timeColumn"`
+	// AlertMode checks that the frame is a single numeric series with one time field, as alerting expects
+	AlertMode bool `json:"alertMode"`
+	// BigNumberAsString are numeric columns, such as IDs, read as strings holding every digit of the value
+	BigNumberAsString []string `json:"bigNumberAsString"`
+	// SkipEmptyRows drops the rows without any values, e.g. blank rows between groups of a table
+	SkipEmptyRows bool `json:"skipEmptyRows"`
+	// NewlineHandling is how line breaks in strings are returned: "keep" (default), "space" or "strip"
+	NewlineHandling string `json:"newlineHandling"`
+	// RevisionID pins the query to a revision of the spreadsheet. Only the latest revision can be read
+	RevisionID string `json:"revisionId"`
+	// DerivedColumns are fields calculated from other columns, e.g. "price * qty"
+	DerivedColumns []DerivedColumn `json:"derivedColumns"`
+	// FramePerSheet returns a frame named after each tab instead of concatenating the tabs
+	FramePerSheet bool `json:"framePerSheet"`
+	// MaxStringLength truncates longer strings with an ellipsis, 0 for no limit
+	MaxStringLength int `json:"maxStringLength"`
+	// IncludeColumnFormulas reports the formula of formula-backed columns in the frame meta
+	IncludeColumnFormulas bool `json:"includeColumnFormulas"`
+	// TextPercentages converts columns of text like "25%" to numbers with a percent unit
+	TextPercentages bool `json:"textPercentages"`
+	// PercentAsFraction stores text percentages as fractions, 0.25 rather than 25 for "25%"
+	PercentAsFraction bool `json:"percentAsFraction"`
+	// CoercionFallback is the value used by column instead of null for cells that don't fit the column type
+	CoercionFallback map[string]interface{} `json:"coercionFallback"`
+	// AutoExpandRange extends a range ending at a row to the last row with data when the data goes past it
+	AutoExpandRange bool `json:"autoExpandRange"`
+	// Matrix reads the range as a grid for heatmaps, the same way as RowHeaderColumn
+	Matrix bool `json:"matrix"`
+	// ColumnOrder lists the columns that come first in the frame, the others follow in sheet order
+	ColumnOrder []string `json:"columnOrder"`
+	// PublishedCSVURL reads a sheet published to the web as CSV instead of using the API, which needs no credentials
+	PublishedCSVURL string `json:"publishedCsvUrl"`
+	// Filter keeps the rows matching a macro, e.g. $__timeFilter(Date) for the rows within the dashboard time range
+	Filter string `json:"filter"`
+	// SheetName, StartCell and EndCell are composed into the range, as an alternative to writing it in A1 notation
+	SheetName string `json:"sheetName"`
+	StartCell string `json:"startCell"`
+	EndCell   string `json:"endCell"`
+	// ErrorCellPolicy is how formula errors like #N/A are read: null (default), keepString or error
+	ErrorCellPolicy string `json:"errorCellPolicy"`
+	// ComputeFieldRanges sets the min and max of number fields to their observed range, e.g. for gauges
+	ComputeFieldRanges bool `json:"computeFieldRanges"`
+	// Transposed reads one record per column, with the field names in the first column instead of the first row
+	Transposed bool `json:"transposed"`
+	// ConstantFields adds a string field with a fixed value to every row by name, e.g. to tell merged frames apart
+	ConstantFields map[string]string `json:"constantFields"`
+	// SplitColumns splits the text of a column by its delimiter into the fields <column>_1, <column>_2 and so on
+	SplitColumns map[string]string `json:"splitColumns"`
+	// IncludeRawValues adds the formatted values of the range as read from the sheet to the frame meta, for debugging
+	IncludeRawValues bool `json:"includeRawValues"`
+	// RawValuesLimit is the number of raw values included, 0 for the default of 1000
+	RawValuesLimit int `json:"rawValuesLimit"`
+	// SingleRowAsScalars returns a frame per column when there is only one row, for stat panels
+	SingleRowAsScalars bool `json:"singleRowAsScalars"`
+	// HeaderRange is read for the column names, when they are not on top of the data in Range
+	HeaderRange string `json:"headerRange"`
+	// ForwardFillColumns fill empty cells with the value above them, for sheets that leave repeated values blank
+	ForwardFillColumns []string `json:"forwardFillColumns"`
+	// SheetRanges reads a range per tab by tab name in one request, returning a frame per tab instead of reading Range
+	SheetRanges map[string]string `json:"sheetRanges"`
+	// NumericStrip removes substrings like a currency code from the text of a column by name, so it reads as numbers
+	NumericStrip map[string][]string `json:"numericStrip"`
+	// Stream polls the range of an append-style sheet and pushes the new rows over a live channel
+	Stream bool `json:"stream"`
+	// StreamIntervalSeconds is how often a streamed range is polled, 0 for the default
+	StreamIntervalSeconds int `json:"streamIntervalSeconds"`
+	// IncludeRowRange adds a _rowRange field with the A1 range of the sheet row of each row, e.g. for write-back
+	IncludeRowRange bool `json:"includeRowRange"`
+	// CaseInsensitiveColumns matches the column names of the options, such as ColumnTypes, GroupByColumn, Filter and
+	// JoinKeys, to the headers ignoring case. The columns in DerivedColumns expressions must match exactly
+	CaseInsensitiveColumns bool `json:"caseInsensitiveColumns"`
+	// GroupByColumn returns one row per distinct value of the column, with the Aggregations of other columns
+	GroupByColumn string `json:"groupByColumn"`
+	// Aggregations maps a column to the function it is aggregated with per group: sum, avg, count, min or max
+	Aggregations map[string]string `json:"aggregations"`
+	// ErrorOnEmpty fails the query when the range has no data rows, instead of returning an empty frame
+	ErrorOnEmpty bool `json:"errorOnEmpty"`
+	// RangeFromMetadataKey reads the range from the value of a developer metadata key instead of Range
+	RangeFromMetadataKey string `json:"rangeFromMetadataKey"`
+	// DerivePeriods adds a string field per period (isoweek, month, quarter or year) of the TimeColumn
+	DerivePeriods []string `json:"derivePeriods"`
+	// NullableFields emits number and time fields with nulls for empty cells, true when not set
+	NullableFields *bool `json:"nullableFields"`
+	// EmptyValue is the number empty cells are read as when NullableFields is false, empty times are the Unix epoch
+	EmptyValue float64 `json:"emptyValue"`
+	// NormalizeCurrency converts the amounts of currency columns, even ones mixing currencies, to one currency
+	NormalizeCurrency CurrencyNormalization `json:"normalizeCurrency"`
+	// LastEditedColumn is read as the time each row was last edited, reporting the oldest and newest in the frame meta
+	LastEditedColumn string `json:"lastEditedColumn"`
+	// NormalizeRange quotes the sheet name and uppercases the cells of the range and of the SheetRanges, rejecting
+	// malformed ranges
+	NormalizeRange bool `json:"normalizeRange"`
+	// TimeSeriesWide returns the time column and the number columns, sorted by time, as a wide time series
+	TimeSeriesWide bool `json:"timeSeriesWide"`
 
 	// Not from JSON
 	TimeRange     backend.TimeRange `json:"-"`
 	MaxDataPoints int64             `json:"-"`
+	QueryType     string            `json:"-"`
+	// ErrorCacheDuration is how long transient API errors are cached, from the datasource settings
+	ErrorCacheDuration time.Duration `json:"-"`
+	// Location is the time zone of the spreadsheet times are parsed in, the local time zone when nil
+	Location *time.Location `json:"-"`
+	// CacheJitter is the fraction cache durations are randomly changed by, from the datasource settings
+	CacheJitter float64 `json:"-"`
+	// CacheSizeTiers multiply the cache duration by the cell count, from the datasource settings
+	CacheSizeTiers []CacheSizeTier `json:"-"`
+}
+
+// DateRange is an inclusive range of dates formatted as YYYY-MM-DD.
+type DateRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Sample selects every Nth row and then at most MaxRows of those. Zero disables an option.
+type Sample struct {
+	EveryNth int `json:"everyNth"`
+	MaxRows  int `json:"maxRows"`
+	// FromStart keeps the first MaxRows rows instead of spreading them across all rows
+	FromStart bool `json:"fromStart"`
+}
+
+// DerivedColumn is a field calculated per row from an expression over other columns.
+type DerivedColumn struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// CurrencyNormalization converts amounts to the Target currency, e.g. "USD". Rates are the amount of the target
+// currency one unit of a currency is worth, e.g. {"EUR": 1.1}.
+type CurrencyNormalization struct {
+	Target string             `json:"target"`
+	Rates  map[string]float64 `json:"rates"`
 }
 
 // GetQueryModel returns the well typed query model
@@ -31,5 +243,6 @@ func GetQueryModel(query backend.DataQuery) (*QueryModel, error) {
 	// Copy directly from the well typed query
 	model.TimeRange = query.TimeRange
 	model.MaxDataPoints = query.MaxDataPoints
+	model.QueryType = query.QueryType
 	return model, nil
 }
@@ -9,11 +9,71 @@ import (
 
 // DatasourceSettings contains Google Sheets API authentication properties.
 type DatasourceSettings struct {
+	// ID is the datasource instance the settings belong to
+	ID int64 `json:"-"`
+	// UID is the string identifier of the datasource instance, used in stream channels
+	UID string `json:"-"`
+
 	AuthType string `json:"authType"` // jwt | key
 	APIKey   string `json:"apiKey"`
 	JWT      string `json:"jwt"`
+	// UseDefaultCredentials authenticates with the Application Default Credentials, e.g. GKE Workload Identity, instead of the auth type
+	UseDefaultCredentials bool `json:"useDefaultCredentials"`
+
+	// APIEndpoint overrides the Sheets API base URL, e.g. for emulators or proxies
+	APIEndpoint string `json:"apiEndpoint"`
+
+	// MaxConcurrentFetches limits the API calls a single query can run in parallel
+	MaxConcurrentFetches int `json:"maxConcurrentFetches"`
+
+	// ErrorCacheDurationSeconds is how long transient API errors are cached, negative to disable
+	ErrorCacheDurationSeconds int `json:"errorCacheDurationSeconds"`
+
+	// RequestsPerMinute limits the spreadsheet requests of the datasource, 0 for no limit
+	RequestsPerMinute int `json:"requestsPerMinute"`
+
+	// RetriesPerMinute is the number of transient errors retried per minute across all queries of the datasource, 0 disables retries
+	RetriesPerMinute int `json:"retriesPerMinute"`
+
+	// CacheJitterPercent randomly shortens or lengthens cache durations by up to this much, negative to disable
+	CacheJitterPercent int `json:"cacheJitterPercent"`
+
+	// CacheSizeTiers lengthen or shorten the cache duration of queries by the number of cells they read
+	CacheSizeTiers []CacheSizeTier `json:"cacheSizeTiers"`
+
+	// AllowWrites grants the datasource write access to spreadsheets
+	AllowWrites bool `json:"allowWrites"`
+	// AllowBatchUpdate additionally enables the batchUpdate query type, which can change anything in a spreadsheet
+	AllowBatchUpdate bool `json:"allowBatchUpdate"`
+
+	// ProxyURL routes API requests through an HTTP proxy, instead of the one from the environment
+	ProxyURL      string `json:"proxyUrl"`
+	ProxyUsername string `json:"proxyUsername"`
+	ProxyPassword string `json:"-"`
+
+	// TLSClientCert and TLSClientKey are a PEM client certificate for gateways that require mutual TLS
+	TLSClientCert string `json:"-"`
+	TLSClientKey  string `json:"-"`
+	// TLSCACert is a PEM bundle trusted in addition to the system roots
+	TLSCACert string `json:"-"`
+}
+
+// CacheSizeTier multiplies the cache duration of queries reading at least MinCells cells.
+// The tier with the highest MinCells a query reaches applies.
+type CacheSizeTier struct {
+	MinCells   int     `json:"minCells"`
+	Multiplier float64 `json:"multiplier"`
 }
 
+const (
+	// DefaultMaxConcurrentFetches is used when MaxConcurrentFetches is not configured.
+	DefaultMaxConcurrentFetches = 4
+	// DefaultErrorCacheDurationSeconds is used when ErrorCacheDurationSeconds is not configured.
+	DefaultErrorCacheDurationSeconds = 5
+	// DefaultCacheJitterPercent is used when CacheJitterPercent is not configured.
+	DefaultCacheJitterPercent = 10
+)
+
 // LoadSettings gets the relevant settings from the plugin context
 func LoadSettings(ctx backend.PluginContext) (*DatasourceSettings, error) {
 	model := &DatasourceSettings{}
@@ -24,8 +84,24 @@ func LoadSettings(ctx backend.PluginContext) (*DatasourceSettings, error) {
 		return nil, fmt.Errorf("error reading settings: %s", err.Error())
 	}
 
+	model.ID = settings.ID
+	model.UID = settings.UID
 	model.APIKey = settings.DecryptedSecureJSONData["apiKey"]
 	model.JWT = settings.DecryptedSecureJSONData["jwt"]
+	model.ProxyPassword = settings.DecryptedSecureJSONData["proxyPassword"]
+	model.TLSClientCert = settings.DecryptedSecureJSONData["tlsClientCert"]
+	model.TLSClientKey = settings.DecryptedSecureJSONData["tlsClientKey"]
+	model.TLSCACert = settings.DecryptedSecureJSONData["tlsCACert"]
+
+	if model.MaxConcurrentFetches <= 0 {
+		model.MaxConcurrentFetches = DefaultMaxConcurrentFetches
+	}
+	if model.ErrorCacheDurationSeconds == 0 {
+		model.ErrorCacheDurationSeconds = DefaultErrorCacheDurationSeconds
+	}
+	if model.CacheJitterPercent == 0 {
+		model.CacheJitterPercent = DefaultCacheJitterPercent
+	}
 
 	return model, nil
 }
@@ -40,18 +40,144 @@ export interface SheetResponseMeta {
 // The Sheets specific types
 //-------------------------------------------------------------------------------
 
+export interface DateRange {
+  from: string;
+  to: string;
+}
+
+export interface Sample {
+  everyNth?: number;
+  maxRows?: number;
+  fromStart?: boolean;
+}
+
+export interface DerivedColumn {
+  name: string;
+  expression: string;
+}
+
+export interface CurrencyNormalization {
+  target: string;
+  rates?: Record<string, number>;
+}
+
 export interface SheetsQuery extends DataQuery {
   spreadsheet: string;
   range?: string;
   cacheDurationSeconds?: number;
   useTimeFilter?: boolean;
+  labelColumn?: string;
+  refreshOnGrowth?: boolean;
+  frameName?: string;
+  extractImages?: boolean;
+  waitForConsistency?: boolean;
+  anchorCellValue?: string;
+  anchorRowOffset?: number;
+  anchorColumnOffset?: number;
+  regexFilter?: Record<string, string>;
+  collectRowErrors?: boolean;
+  trimStrings?: boolean;
+  useFrozenRowsAsHeader?: boolean;
+  timeAsEpochMillis?: boolean;
+  tabDateRange?: DateRange;
+  timeOffset?: string;
+  columnIncludeGlob?: string;
+  columnExcludeGlob?: string;
+  rowHeaderColumn?: boolean;
+  typeDetectionSampleSize?: number;
+  batchUpdateRequest?: object;
+  columnTypes?: Record<string, string>;
+  schemaRange?: string;
+  scale?: Record<string, number>;
+  duplicateSuffixFormat?: string;
+  epochSecondsColumns?: string[];
+  epochMillisColumns?: string[];
+  sample?: Sample;
+  respectHiddenRows?: boolean;
+  pivotColumn?: string;
+  pivotValueColumn?: string;
+  pivotIndexColumns?: string[];
+  credentials?: string;
+  joinRange?: string;
+  joinKeys?: string[];
+  nullTokens?: string[];
+  reverseRows?: boolean;
+  timeColumn?: string;
+  alertMode?: boolean;
+  bigNumberAsString?: string[];
+  skipEmptyRows?: boolean;
+  newlineHandling?: 'keep' | 'space' | 'strip';
+  revisionId?: string;
+  derivedColumns?: DerivedColumn[];
+  framePerSheet?: boolean;
+  maxStringLength?: number;
+  includeColumnFormulas?: boolean;
+  textPercentages?: boolean;
+  percentAsFraction?: boolean;
+  coercionFallback?: Record<string, number | string>;
+  autoExpandRange?: boolean;
+  matrix?: boolean;
+  columnOrder?: string[];
+  publishedCsvUrl?: string;
+  filter?: string;
+  sheetName?: string;
+  startCell?: string;
+  endCell?: string;
+  errorCellPolicy?: 'null' | 'keepString' | 'error';
+  computeFieldRanges?: boolean;
+  transposed?: boolean;
+  constantFields?: Record<string, string>;
+  splitColumns?: Record<string, string>;
+  includeRawValues?: boolean;
+  rawValuesLimit?: number;
+  singleRowAsScalars?: boolean;
+  headerRange?: string;
+  forwardFillColumns?: string[];
+  sheetRanges?: Record<string, string>;
+  numericStrip?: Record<string, string[]>;
+  stream?: boolean;
+  streamIntervalSeconds?: number;
+  includeRowRange?: boolean;
+  caseInsensitiveColumns?: boolean;
+  groupByColumn?: string;
+  aggregations?: Record<string, 'sum' | 'avg' | 'count' | 'min' | 'max'>;
+  errorOnEmpty?: boolean;
+  rangeFromMetadataKey?: string;
+  derivePeriods?: ('isoweek' | 'month' | 'quarter' | 'year')[];
+  nullableFields?: boolean;
+  emptyValue?: number;
+  normalizeCurrency?: CurrencyNormalization;
+  lastEditedColumn?: string;
+  normalizeRange?: boolean;
+  timeSeriesWide?: boolean;
+}
+
+export interface CacheSizeTier {
+  minCells: number;
+  multiplier: number;
 }
 
 export interface SheetsSourceOptions extends DataSourceJsonData {
   authType: GoogleAuthType;
+  useDefaultCredentials?: boolean;
+  apiEndpoint?: string;
+  maxConcurrentFetches?: number;
+  errorCacheDurationSeconds?: number;
+  cacheJitterPercent?: number;
+  cacheSizeTiers?: CacheSizeTier[];
+  requestsPerMinute?: number;
+  retriesPerMinute?: number;
+  allowWrites?: boolean;
+  allowBatchUpdate?: boolean;
+  proxyUrl?: string;
+  proxyUsername?: string;
 }
 
 export interface GoogleSheetsSecureJsonData {
   apiKey?: string;
   jwt?: string;
+  proxyPassword?: string;
+  tlsClientCert?: string;
+  tlsClientKey?: string;
+  tlsCACert?: string;
 }